@@ -0,0 +1,330 @@
+// Package database provides the SQL-backed accesscontrol.RoleStore
+// implementation, persisting custom roles and their grants alongside the
+// hard-coded FixedRolesMap.
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// userGrantPrefix marks a GrantRole/RevokeRole subject as a user rather than
+// a built-in role, per accesscontrol.RoleStore's "user:<id>" convention.
+const userGrantPrefix = "user:"
+
+// roleStore implements accesscontrol.RoleStore against the Grafana
+// database.
+type roleStore struct {
+	sql *sqlstore.SQLStore
+}
+
+// ProvideRoleStore returns the SQL-backed accesscontrol.RoleStore.
+func ProvideRoleStore(sql *sqlstore.SQLStore) accesscontrol.RoleStore {
+	return &roleStore{sql: sql}
+}
+
+// roleRow mirrors the `role` table. It is kept separate from
+// accesscontrol.Role so the xorm struct tags don't leak into the service
+// layer type.
+type roleRow struct {
+	ID          int64     `xorm:"pk autoincr 'id'"`
+	OrgID       int64     `xorm:"org_id"`
+	Name        string    `xorm:"name"`
+	DisplayName string    `xorm:"display_name"`
+	Description string    `xorm:"description"`
+	Group       string    `xorm:"group_name"`
+	Hidden      bool      `xorm:"hidden"`
+	Revision    int64     `xorm:"revision"`
+	Created     time.Time `xorm:"created"`
+	Updated     time.Time `xorm:"updated"`
+}
+
+func (r roleRow) TableName() string { return "role" }
+
+func (r roleRow) toRole(permissions []accesscontrol.Permission) *accesscontrol.Role {
+	return &accesscontrol.Role{
+		ID:          r.ID,
+		OrgID:       r.OrgID,
+		Name:        r.Name,
+		DisplayName: r.DisplayName,
+		Description: r.Description,
+		Group:       r.Group,
+		Hidden:      r.Hidden,
+		Revision:    r.Revision,
+		Permissions: permissions,
+		Created:     r.Created,
+		Updated:     r.Updated,
+	}
+}
+
+// permissionRow mirrors the `permission` table, scoped to a single role.
+type permissionRow struct {
+	ID      int64  `xorm:"pk autoincr 'id'"`
+	RoleID  int64  `xorm:"role_id"`
+	Action  string `xorm:"action"`
+	Scope   string `xorm:"scope"`
+	Updated time.Time
+	Created time.Time
+}
+
+func (permissionRow) TableName() string { return "permission" }
+
+func (s *roleStore) CreateRole(ctx context.Context, orgID int64, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.Role, error) {
+	var role *accesscontrol.Role
+
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id = ? AND name = ?", orgID, cmd.Name).Get(new(roleRow))
+		if err != nil {
+			return err
+		}
+		if has {
+			return accesscontrol.ErrRoleAlreadyExists
+		}
+
+		now := time.Now()
+		row := &roleRow{
+			OrgID:       orgID,
+			Name:        cmd.Name,
+			DisplayName: cmd.DisplayName,
+			Description: cmd.Description,
+			Group:       cmd.Group,
+			Hidden:      cmd.Hidden,
+			Revision:    1,
+			Created:     now,
+			Updated:     now,
+		}
+		if _, err := sess.Insert(row); err != nil {
+			return fmt.Errorf("failed to insert role %q: %w", cmd.Name, err)
+		}
+
+		if err := insertPermissions(sess, row.ID, cmd.Permissions); err != nil {
+			return err
+		}
+
+		role = row.toRole(cmd.Permissions)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accesscontrol.BumpOrgAuthRevision(orgID)
+	return role, nil
+}
+
+func (s *roleStore) UpdateRole(ctx context.Context, orgID int64, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.Role, error) {
+	var role *accesscontrol.Role
+
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		row := new(roleRow)
+		has, err := sess.Where("org_id = ? AND name = ?", orgID, cmd.Name).Get(row)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return accesscontrol.ErrRoleNotFound
+		}
+
+		row.DisplayName = cmd.DisplayName
+		row.Description = cmd.Description
+		row.Revision++
+		row.Updated = time.Now()
+		if _, err := sess.ID(row.ID).Cols("display_name", "description", "revision", "updated").Update(row); err != nil {
+			return fmt.Errorf("failed to update role %q: %w", cmd.Name, err)
+		}
+
+		if _, err := sess.Where("role_id = ?", row.ID).Delete(new(permissionRow)); err != nil {
+			return fmt.Errorf("failed to clear permissions for role %q: %w", cmd.Name, err)
+		}
+		if err := insertPermissions(sess, row.ID, cmd.Permissions); err != nil {
+			return err
+		}
+
+		role = row.toRole(cmd.Permissions)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accesscontrol.BumpOrgAuthRevision(orgID)
+	return role, nil
+}
+
+func (s *roleStore) DeleteRole(ctx context.Context, orgID int64, name string) error {
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		row := new(roleRow)
+		has, err := sess.Where("org_id = ? AND name = ?", orgID, name).Get(row)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return accesscontrol.ErrRoleNotFound
+		}
+
+		// The role migrations define no foreign keys, so the cascade to
+		// permission, builtin_role and user_role rows is done here.
+		if _, err := sess.Where("role_id = ?", row.ID).Delete(new(permissionRow)); err != nil {
+			return err
+		}
+		if _, err := sess.Where("role_id = ?", row.ID).Delete(new(builtinRoleGrantRow)); err != nil {
+			return err
+		}
+		if _, err := sess.Where("role_id = ?", row.ID).Delete(new(userRoleGrantRow)); err != nil {
+			return err
+		}
+		if _, err := sess.ID(row.ID).Delete(new(roleRow)); err != nil {
+			return fmt.Errorf("failed to delete role %q: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	accesscontrol.BumpOrgAuthRevision(orgID)
+	return nil
+}
+
+func (s *roleStore) ListRoles(ctx context.Context, orgID int64) ([]accesscontrol.Role, error) {
+	var roles []accesscontrol.Role
+
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var rows []roleRow
+		if err := sess.Where("org_id = ?", orgID).Find(&rows); err != nil {
+			return err
+		}
+
+		roles = make([]accesscontrol.Role, 0, len(rows))
+		for _, row := range rows {
+			var perms []permissionRow
+			if err := sess.Where("role_id = ?", row.ID).Find(&perms); err != nil {
+				return err
+			}
+
+			permissions := make([]accesscontrol.Permission, 0, len(perms))
+			for _, p := range perms {
+				permissions = append(permissions, accesscontrol.Permission{Action: p.Action, Scope: p.Scope})
+			}
+			roles = append(roles, *row.toRole(permissions))
+		}
+		return nil
+	})
+
+	return roles, err
+}
+
+// builtinRoleGrantRow mirrors the `builtin_role` table: a grant of a role to
+// a built-in role (e.g. "Editor").
+type builtinRoleGrantRow struct {
+	ID      int64  `xorm:"pk autoincr 'id'"`
+	OrgID   int64  `xorm:"org_id"`
+	RoleID  int64  `xorm:"role_id"`
+	Role    string `xorm:"role"`
+	Created time.Time
+}
+
+func (builtinRoleGrantRow) TableName() string { return "builtin_role" }
+
+// userRoleGrantRow mirrors the `user_role` table: a grant of a role directly
+// to a user, identified as "user:<id>".
+type userRoleGrantRow struct {
+	ID      int64  `xorm:"pk autoincr 'id'"`
+	OrgID   int64  `xorm:"org_id"`
+	RoleID  int64  `xorm:"role_id"`
+	Subject string `xorm:"subject"`
+	Created time.Time
+}
+
+func (userRoleGrantRow) TableName() string { return "user_role" }
+
+func (s *roleStore) GrantRole(ctx context.Context, orgID int64, builtInOrUser string, roleName string) error {
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		row := new(roleRow)
+		has, err := sess.Where("org_id = ? AND name = ?", orgID, roleName).Get(row)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return accesscontrol.ErrRoleNotFound
+		}
+
+		if strings.HasPrefix(builtInOrUser, userGrantPrefix) {
+			exists, err := sess.Where("org_id = ? AND role_id = ? AND subject = ?", orgID, row.ID, builtInOrUser).
+				Get(new(userRoleGrantRow))
+			if err != nil {
+				return err
+			}
+			if exists {
+				return nil // already granted; provisioning re-runs are idempotent
+			}
+			_, err = sess.Insert(&userRoleGrantRow{OrgID: orgID, RoleID: row.ID, Subject: builtInOrUser, Created: time.Now()})
+			return err
+		}
+
+		exists, err := sess.Where("org_id = ? AND role_id = ? AND role = ?", orgID, row.ID, builtInOrUser).
+			Get(new(builtinRoleGrantRow))
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil // already granted; provisioning re-runs are idempotent
+		}
+		_, err = sess.Insert(&builtinRoleGrantRow{OrgID: orgID, RoleID: row.ID, Role: builtInOrUser, Created: time.Now()})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	accesscontrol.BumpOrgAuthRevision(orgID)
+	return nil
+}
+
+func (s *roleStore) RevokeRole(ctx context.Context, orgID int64, builtInOrUser string, roleName string) error {
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		row := new(roleRow)
+		has, err := sess.Where("org_id = ? AND name = ?", orgID, roleName).Get(row)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return nil
+		}
+
+		if strings.HasPrefix(builtInOrUser, userGrantPrefix) {
+			_, err = sess.Where("org_id = ? AND role_id = ? AND subject = ?", orgID, row.ID, builtInOrUser).Delete(new(userRoleGrantRow))
+			return err
+		}
+
+		_, err = sess.Where("org_id = ? AND role_id = ? AND role = ?", orgID, row.ID, builtInOrUser).Delete(new(builtinRoleGrantRow))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	accesscontrol.BumpOrgAuthRevision(orgID)
+	return nil
+}
+
+func insertPermissions(sess *sqlstore.DBSession, roleID int64, permissions []accesscontrol.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]permissionRow, 0, len(permissions))
+	for _, p := range permissions {
+		rows = append(rows, permissionRow{RoleID: roleID, Action: p.Action, Scope: p.Scope, Created: now, Updated: now})
+	}
+	if _, err := sess.Insert(&rows); err != nil {
+		return fmt.Errorf("failed to insert permissions for role %d: %w", roleID, err)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package accesscontrol
+
+import "errors"
+
+var (
+	// ErrRoleNotFound is returned by RoleStore when a custom role lookup by
+	// (orgID, name) finds nothing.
+	ErrRoleNotFound = errors.New("accesscontrol: role not found")
+
+	// ErrRoleAlreadyExists is returned by RoleStore.CreateRole when a role
+	// with the same (orgID, name) is already persisted. Callers that want
+	// provisioning-style upsert semantics should fall back to UpdateRole on
+	// this specific error, not on any CreateRole failure.
+	ErrRoleAlreadyExists = errors.New("accesscontrol: role already exists")
+)
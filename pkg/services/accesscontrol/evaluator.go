@@ -0,0 +1,138 @@
+package accesscontrol
+
+import "strings"
+
+// CompiledAction is the evaluator's per-action index over a subject's
+// scopes: exact scopes (no trailing wildcard segment) are looked up in a
+// hash set, while scopes like "users:*" or "datasources:uid:*" are compiled
+// once into a trie so a check walks at most depth(scope) nodes instead of
+// re-parsing every granted scope on every call.
+type CompiledAction struct {
+	exact map[string]struct{}
+	trie  *scopeNode
+}
+
+func compileAction(scopes []string) *CompiledAction {
+	compiled := &CompiledAction{
+		exact: make(map[string]struct{}),
+		trie:  newScopeNode(),
+	}
+
+	for _, scope := range scopes {
+		if scope == "" {
+			// A permission with no scope is stored as the empty exact match,
+			// which HasAccess checks only for an empty requested scope.
+			compiled.exact[""] = struct{}{}
+			continue
+		}
+
+		segments := strings.Split(scope, ":")
+		if segments[len(segments)-1] == "*" {
+			compiled.trie.insert(segments)
+		} else {
+			compiled.exact[scope] = struct{}{}
+		}
+	}
+
+	return compiled
+}
+
+// matches reports whether scope is covered by this action's exact set or
+// wildcard trie.
+func (c *CompiledAction) matches(scope string) bool {
+	if _, ok := c.exact[scope]; ok {
+		return true
+	}
+	return c.trie.matches(strings.Split(scope, ":"))
+}
+
+// scopeNode is one node of the wildcard scope trie, one child per ":"
+// separated segment. A node with wildcard set means every scope sharing the
+// path down to it (with any, including zero, trailing segments) matches.
+type scopeNode struct {
+	children map[string]*scopeNode
+	wildcard bool
+}
+
+func newScopeNode() *scopeNode {
+	return &scopeNode{children: make(map[string]*scopeNode)}
+}
+
+func (n *scopeNode) insert(segments []string) {
+	cur := n
+	for _, seg := range segments {
+		if seg == "*" {
+			cur.wildcard = true
+			return
+		}
+
+		next, ok := cur.children[seg]
+		if !ok {
+			next = newScopeNode()
+			cur.children[seg] = next
+		}
+		cur = next
+	}
+}
+
+func (n *scopeNode) matches(segments []string) bool {
+	cur := n
+	if cur.wildcard {
+		return true
+	}
+
+	for _, seg := range segments {
+		next, ok := cur.children[seg]
+		if !ok {
+			return false
+		}
+		cur = next
+		if cur.wildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SubjectEvaluator is a subject's effective permissions (built-in role +
+// granted fixed roles + custom roles), pre-compiled into an indexed
+// structure so HasAccess is O(1) in the number of granted permissions and
+// O(depth) in the scope, instead of re-scanning every permission on every
+// call.
+type SubjectEvaluator struct {
+	actions map[string]*CompiledAction
+}
+
+// CompilePermissions builds a SubjectEvaluator from a subject's flattened
+// permission set.
+func CompilePermissions(permissions []Permission) *SubjectEvaluator {
+	byAction := make(map[string][]string)
+	for _, p := range permissions {
+		byAction[p.Action] = append(byAction[p.Action], p.Scope)
+	}
+
+	actions := make(map[string]*CompiledAction, len(byAction))
+	for action, scopes := range byAction {
+		actions[action] = compileAction(scopes)
+	}
+
+	return &SubjectEvaluator{actions: actions}
+}
+
+// HasAccess reports whether the compiled subject holds action over scope.
+// An empty scope only matches a permission that was itself granted without
+// a scope.
+func (e *SubjectEvaluator) HasAccess(action, scope string) bool {
+	compiled, ok := e.actions[action]
+	if !ok {
+		return false
+	}
+
+	if scope == "" {
+		_, ok := compiled.exact[""]
+		return ok
+	}
+
+	return compiled.matches(scope)
+}
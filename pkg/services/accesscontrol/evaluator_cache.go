@@ -0,0 +1,102 @@
+package accesscontrol
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// evaluatorCacheKey identifies one subject's cached evaluator within a
+// single org; the same subjectID in two different orgs (e.g. a user who
+// belongs to both) must never share an entry, since their effective
+// permissions and invalidation are independent per org.
+type evaluatorCacheKey struct {
+	orgID     int64
+	subjectID string
+}
+
+// evaluatorCacheEntry pins a compiled evaluator to the global and
+// org-scoped auth revisions it was compiled under; a mismatch against
+// either on lookup means something granted, revoked, or otherwise mutated
+// permissions since, and the entry must be recompiled.
+type evaluatorCacheEntry struct {
+	globalRevision int64
+	orgRevision    int64
+	evaluator      *SubjectEvaluator
+}
+
+// evaluatorCache caches one SubjectEvaluator per (org, subject) pair,
+// invalidated by the global and per-org auth revisions (see AuthRevision
+// and OrgAuthRevision) rather than a TTL: a cached decision is only ever
+// stale for as long as it takes the next permission mutation to bump the
+// relevant revision, not for some arbitrary window.
+type evaluatorCache struct {
+	mu      sync.RWMutex
+	entries map[evaluatorCacheKey]evaluatorCacheEntry
+}
+
+var globalEvaluatorCache = &evaluatorCache{entries: make(map[evaluatorCacheKey]evaluatorCacheEntry)}
+
+// getOrCompile returns the cached evaluator for (orgID, subjectID) if it
+// was built at the current global and org auth revisions, compiling (and
+// caching) a fresh one via permissions otherwise.
+func (c *evaluatorCache) getOrCompile(orgID int64, subjectID string, permissions func() []Permission) *SubjectEvaluator {
+	key := evaluatorCacheKey{orgID: orgID, subjectID: subjectID}
+	globalRevision, orgRevision := AuthRevision(), OrgAuthRevision(orgID)
+
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok && entry.globalRevision == globalRevision && entry.orgRevision == orgRevision {
+		evaluatorCacheHits.Inc()
+		return entry.evaluator
+	}
+
+	evaluatorCacheMisses.Inc()
+
+	start := time.Now()
+	evaluator := CompilePermissions(permissions())
+	evaluatorCompileDuration.Observe(time.Since(start).Seconds())
+
+	c.mu.Lock()
+	c.entries[key] = evaluatorCacheEntry{globalRevision: globalRevision, orgRevision: orgRevision, evaluator: evaluator}
+	c.mu.Unlock()
+
+	return evaluator
+}
+
+// Bump drops the cached evaluator for (orgID, subjectID), forcing the next
+// lookup to recompile it even if neither auth revision has moved. Used
+// when a subject-specific change (e.g. FixedRoleGrantsMap being edited
+// directly, outside of a RoleStore mutation) should invalidate just that
+// subject.
+func (c *evaluatorCache) Bump(orgID int64, subjectID string) {
+	c.mu.Lock()
+	delete(c.entries, evaluatorCacheKey{orgID: orgID, subjectID: subjectID})
+	c.mu.Unlock()
+}
+
+// Bump drops (orgID, subjectID)'s cached evaluator from the process-wide
+// evaluator cache.
+func Bump(orgID int64, subjectID string) {
+	globalEvaluatorCache.Bump(orgID, subjectID)
+}
+
+type evaluatorContextKey struct{}
+
+// WithCachedEvaluator compiles (or reuses, keyed by orgID, subjectID and
+// the current auth revisions) subjectID's SubjectEvaluator and attaches it
+// to ctx, analogous to Coder's WithCachedASTValue. Middleware calls this
+// once per request so downstream HasAccess calls never recompile
+// permissions.
+func WithCachedEvaluator(ctx context.Context, orgID int64, subjectID string, permissions func() []Permission) context.Context {
+	evaluator := globalEvaluatorCache.getOrCompile(orgID, subjectID, permissions)
+	return context.WithValue(ctx, evaluatorContextKey{}, evaluator)
+}
+
+// EvaluatorFromContext returns the SubjectEvaluator attached by
+// WithCachedEvaluator, if any.
+func EvaluatorFromContext(ctx context.Context) (*SubjectEvaluator, bool) {
+	evaluator, ok := ctx.Value(evaluatorContextKey{}).(*SubjectEvaluator)
+	return evaluator, ok
+}
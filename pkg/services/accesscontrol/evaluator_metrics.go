@@ -0,0 +1,33 @@
+package accesscontrol
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "grafana"
+const metricsSubsystem = "accesscontrol_evaluator"
+
+var (
+	evaluatorCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "cache_hits_total",
+		Help:      "Number of times a subject's compiled permission evaluator was served from cache.",
+	})
+
+	evaluatorCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "cache_misses_total",
+		Help:      "Number of times a subject's compiled permission evaluator had to be (re)compiled.",
+	})
+
+	evaluatorCompileDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "compile_duration_seconds",
+		Help:      "Time spent compiling a subject's permissions into a SubjectEvaluator.",
+		Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 8),
+	})
+)
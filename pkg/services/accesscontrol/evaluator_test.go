@@ -0,0 +1,119 @@
+package accesscontrol
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubjectEvaluator_HasAccess(t *testing.T) {
+	evaluator := CompilePermissions([]Permission{
+		{Action: "users:read", Scope: "users:id:1"},
+		{Action: "users:read", Scope: "users:id:2"},
+		{Action: "datasources:read", Scope: "datasources:*"},
+		{Action: "orgs:read"},
+	})
+
+	tests := []struct {
+		name   string
+		action string
+		scope  string
+		want   bool
+	}{
+		{"exact match", "users:read", "users:id:1", true},
+		{"exact scope not granted", "users:read", "users:id:3", false},
+		{"wildcard matches any suffix", "datasources:read", "datasources:uid:abc", true},
+		{"wildcard matches itself", "datasources:read", "datasources:*", true},
+		{"unknown action", "users:write", "users:id:1", false},
+		{"unscoped permission, any scope ignored via empty scope", "orgs:read", "", true},
+		{"unscoped permission does not grant a specific scope", "orgs:read", "orgs:id:1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, evaluator.HasAccess(tt.action, tt.scope))
+		})
+	}
+}
+
+func TestEvaluatorCache_InvalidatedByAuthRevision(t *testing.T) {
+	cache := &evaluatorCache{entries: make(map[evaluatorCacheKey]evaluatorCacheEntry)}
+
+	compiles := 0
+	perms := func() []Permission {
+		compiles++
+		return []Permission{{Action: "users:read", Scope: "users:id:1"}}
+	}
+
+	first := cache.getOrCompile(1, "user:1", perms)
+	second := cache.getOrCompile(1, "user:1", perms)
+	require.Same(t, first, second)
+	require.Equal(t, 1, compiles)
+
+	bumpAuthRevision()
+
+	third := cache.getOrCompile(1, "user:1", perms)
+	require.NotSame(t, first, third)
+	require.Equal(t, 2, compiles)
+}
+
+func TestEvaluatorCache_InvalidatedByOrgAuthRevision(t *testing.T) {
+	cache := &evaluatorCache{entries: make(map[evaluatorCacheKey]evaluatorCacheEntry)}
+
+	compiles := 0
+	perms := func() []Permission {
+		compiles++
+		return []Permission{{Action: "users:read", Scope: "users:id:1"}}
+	}
+
+	// Two orgs, same subjectID: each gets its own cache entry, and bumping
+	// one org's revision must not affect the other's.
+	org1First := cache.getOrCompile(1, "user:1", perms)
+	org2First := cache.getOrCompile(2, "user:1", perms)
+	require.Equal(t, 2, compiles)
+
+	BumpOrgAuthRevision(1)
+
+	org1Second := cache.getOrCompile(1, "user:1", perms)
+	require.NotSame(t, org1First, org1Second)
+	require.Equal(t, 3, compiles)
+
+	org2Second := cache.getOrCompile(2, "user:1", perms)
+	require.Same(t, org2First, org2Second)
+	require.Equal(t, 3, compiles)
+}
+
+func TestEvaluatorCache_Bump(t *testing.T) {
+	cache := &evaluatorCache{entries: make(map[evaluatorCacheKey]evaluatorCacheEntry)}
+
+	compiles := 0
+	perms := func() []Permission {
+		compiles++
+		return nil
+	}
+
+	cache.getOrCompile(1, "user:1", perms)
+	cache.Bump(1, "user:1")
+	cache.getOrCompile(1, "user:1", perms)
+
+	require.Equal(t, 2, compiles)
+}
+
+func BenchmarkSubjectEvaluator_HasAccess(b *testing.B) {
+	permissions := make([]Permission, 0, 500)
+	for i := 0; i < 500; i++ {
+		permissions = append(permissions, Permission{
+			Action: fmt.Sprintf("resource%d:read", i%50),
+			Scope:  fmt.Sprintf("resource%d:id:%d", i%50, i),
+		})
+	}
+	evaluator := CompilePermissions(permissions)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 10000; j++ {
+			evaluator.HasAccess("resource10:read", "resource10:id:123")
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+type fakeRoleStore struct {
+	roles  map[string]*accesscontrol.Role
+	grants map[string]bool
+
+	createCalls int
+	updateCalls int
+	grantCalls  int
+
+	failCreateWith error
+}
+
+func newFakeRoleStore() *fakeRoleStore {
+	return &fakeRoleStore{roles: map[string]*accesscontrol.Role{}, grants: map[string]bool{}}
+}
+
+func (f *fakeRoleStore) CreateRole(ctx context.Context, orgID int64, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.Role, error) {
+	f.createCalls++
+	if f.failCreateWith != nil {
+		return nil, f.failCreateWith
+	}
+	if _, ok := f.roles[cmd.Name]; ok {
+		return nil, accesscontrol.ErrRoleAlreadyExists
+	}
+	role := &accesscontrol.Role{Name: cmd.Name, DisplayName: cmd.DisplayName, Permissions: cmd.Permissions, Revision: 1}
+	f.roles[cmd.Name] = role
+	return role, nil
+}
+
+func (f *fakeRoleStore) UpdateRole(ctx context.Context, orgID int64, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.Role, error) {
+	f.updateCalls++
+	role, ok := f.roles[cmd.Name]
+	if !ok {
+		return nil, accesscontrol.ErrRoleNotFound
+	}
+	role.DisplayName = cmd.DisplayName
+	role.Permissions = cmd.Permissions
+	role.Revision++
+	return role, nil
+}
+
+func (f *fakeRoleStore) GrantRole(ctx context.Context, orgID int64, builtInOrUser string, roleName string) error {
+	f.grantCalls++
+	f.grants[fmt.Sprintf("%s/%s", builtInOrUser, roleName)] = true
+	return nil
+}
+
+func writeProvisioningFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+const roleYAML = `
+apiVersion: 1
+orgId: 1
+roles:
+  - name: custom:reports:editor
+    displayName: Reports editor
+    grants: ["Editor"]
+    permissions:
+      - action: reports:write
+        scope: reports:*
+`
+
+func TestProvisioner_Provision_CreatesRoleAndGrant(t *testing.T) {
+	dir := t.TempDir()
+	writeProvisioningFile(t, dir, "roles.yaml", roleYAML)
+
+	store := newFakeRoleStore()
+	p := New(store)
+
+	require.NoError(t, p.Provision(context.Background(), dir))
+	require.Equal(t, 1, store.createCalls)
+	require.Equal(t, 0, store.updateCalls)
+	require.True(t, store.grants["Editor/custom:reports:editor"])
+}
+
+func TestProvisioner_Provision_IsIdempotentAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	writeProvisioningFile(t, dir, "roles.yaml", roleYAML)
+
+	store := newFakeRoleStore()
+	p := New(store)
+
+	require.NoError(t, p.Provision(context.Background(), dir))
+	// Simulate a second Grafana start against the same files.
+	require.NoError(t, p.Provision(context.Background(), dir))
+
+	// Every run tries CreateRole first; the second one falls back to UpdateRole.
+	require.Equal(t, 2, store.createCalls)
+	require.Equal(t, 1, store.updateCalls)
+	require.Equal(t, 2, store.grantCalls)
+	require.True(t, store.grants["Editor/custom:reports:editor"])
+}
+
+func TestProvisioner_Provision_CreateErrorOtherThanAlreadyExistsIsNotMasked(t *testing.T) {
+	dir := t.TempDir()
+	writeProvisioningFile(t, dir, "roles.yaml", roleYAML)
+
+	store := newFakeRoleStore()
+	store.failCreateWith = fmt.Errorf("db is on fire")
+	p := New(store)
+
+	err := p.Provision(context.Background(), dir)
+	require.ErrorContains(t, err, "db is on fire")
+	require.Equal(t, 0, store.updateCalls)
+}
+
+func TestProvisioner_Provision_MissingDirectoryIsNotAnError(t *testing.T) {
+	store := newFakeRoleStore()
+	p := New(store)
+	require.NoError(t, p.Provision(context.Background(), filepath.Join(t.TempDir(), "does-not-exist")))
+}
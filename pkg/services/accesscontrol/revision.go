@@ -0,0 +1,61 @@
+package accesscontrol
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// authRevision is a process-wide counter bumped only by changes that affect
+// every org at once: today, just a fixed role being re-registered by
+// InitFixedRole with a higher Version, since FixedRolesMap is shared across
+// all orgs. Cached permission decisions are keyed by (subjectID,
+// authRevision, per-org revision), so bumping this counter invalidates
+// every subject's cache implicitly instead of relying on a TTL. Borrowed
+// from etcd's auth store, which tracks a similar revision per user to
+// invalidate token caches on role/permission changes.
+var authRevision uint64
+
+// orgAuthRevisions holds one counter per org, bumped by custom role and
+// grant mutations (see RoleStore) so that a change in one org doesn't
+// invalidate every other org's cached evaluators. An org with no entry yet
+// reads as revision 0, same as authRevision's zero value before the first
+// bump.
+var orgAuthRevisions sync.Map // map[int64]*uint64
+
+// AuthRevision returns the current global auth revision. Callers should
+// treat it as opaque and only use it for equality comparisons against a
+// previously observed value.
+func AuthRevision() int64 {
+	return int64(atomic.LoadUint64(&authRevision))
+}
+
+// bumpAuthRevision advances the global auth revision, invalidating every
+// previously cached permission decision across every org.
+func bumpAuthRevision() int64 {
+	return int64(atomic.AddUint64(&authRevision, 1))
+}
+
+// BumpAuthRevision is the exported form of bumpAuthRevision, for callers
+// outside this package that mutate state shared across every org (e.g.
+// FixedRolesMap).
+func BumpAuthRevision() int64 {
+	return bumpAuthRevision()
+}
+
+// OrgAuthRevision returns orgID's current auth revision.
+func OrgAuthRevision(orgID int64) int64 {
+	v, ok := orgAuthRevisions.Load(orgID)
+	if !ok {
+		return 0
+	}
+	return int64(atomic.LoadUint64(v.(*uint64)))
+}
+
+// BumpOrgAuthRevision advances orgID's auth revision, invalidating cached
+// permission decisions for subjects of that org alone. Used by RoleStore
+// implementations (e.g. CreateRole/GrantRole) whose mutation is scoped to a
+// single org.
+func BumpOrgAuthRevision(orgID int64) int64 {
+	actual, _ := orgAuthRevisions.LoadOrStore(orgID, new(uint64))
+	return int64(atomic.AddUint64(actual.(*uint64), 1))
+}
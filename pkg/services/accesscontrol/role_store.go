@@ -0,0 +1,80 @@
+package accesscontrol
+
+import (
+	"context"
+	"time"
+)
+
+// Role is the persisted counterpart of a custom (non-fixed) role. Unlike the
+// in-memory RoleDTOs registered in FixedRolesMap, every Role has an OrgID and
+// a monotonically increasing Revision, bumped on every mutation, so that
+// cached permission decisions can be invalidated precisely instead of being
+// flushed on a timer. See BumpOrgAuthRevision.
+type Role struct {
+	ID       int64  `json:"id" xorm:"pk autoincr 'id'"`
+	OrgID    int64  `json:"orgId" xorm:"org_id"`
+	Name     string `json:"name"`
+	Revision int64  `json:"revision"`
+
+	DisplayName string `json:"displayName,omitempty"`
+	Description string `json:"description,omitempty"`
+	Group       string `json:"group,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty"`
+
+	Permissions []Permission `json:"permissions,omitempty" xorm:"-"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// ToRoleDTO projects a persisted Role into the RoleDTO shape used by the rest
+// of the access control evaluation code.
+func (r *Role) ToRoleDTO() RoleDTO {
+	return RoleDTO{
+		Name:        r.Name,
+		DisplayName: r.DisplayName,
+		Description: r.Description,
+		Group:       r.Group,
+		Hidden:      r.Hidden,
+		Version:     r.Revision,
+		Permissions: r.Permissions,
+	}
+}
+
+// CreateRoleCommand describes a custom role to persist.
+type CreateRoleCommand struct {
+	Name        string       `json:"name"`
+	DisplayName string       `json:"displayName"`
+	Description string       `json:"description"`
+	Group       string       `json:"group"`
+	Hidden      bool         `json:"hidden"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// UpdateRoleCommand describes changes to an existing custom role. Name
+// identifies the role to update; the rest of the fields replace its stored
+// values in full (no partial patching).
+type UpdateRoleCommand struct {
+	Name        string       `json:"name"`
+	DisplayName string       `json:"displayName"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// RoleStore persists custom roles beyond the hard-coded FixedRolesMap, and
+// the grants tying those roles to built-in roles or individual users.
+type RoleStore interface {
+	CreateRole(ctx context.Context, orgID int64, cmd CreateRoleCommand) (*Role, error)
+	UpdateRole(ctx context.Context, orgID int64, cmd UpdateRoleCommand) (*Role, error)
+	DeleteRole(ctx context.Context, orgID int64, name string) error
+	ListRoles(ctx context.Context, orgID int64) ([]Role, error)
+
+	// GrantRole assigns roleName to a built-in role (e.g. "Editor") or to a
+	// user, identified as "user:<id>". roleName must be a role stored through
+	// CreateRole; fixed roles held only in FixedRolesMap are not found and
+	// yield ErrRoleNotFound.
+	GrantRole(ctx context.Context, orgID int64, builtInOrUser string, roleName string) error
+	// RevokeRole removes a previously granted assignment. It is a no-op if
+	// the grant does not exist.
+	RevokeRole(ctx context.Context, orgID int64, builtInOrUser string, roleName string) error
+}
@@ -157,8 +157,6 @@ const (
 )
 
 var (
-	once sync.Once
-
 	// FixedRoles provides a map of permission sets/roles which can be
 	// assigned to a set of users. When adding a new resource protected by
 	// Grafana access control the default permissions should be added to a
@@ -176,36 +174,52 @@ func init() {
 	InitFixedRole()
 }
 
+// InitFixedRole (re-)registers every fixed role in FixedRolesMap. It is
+// safe to call more than once: a role is only upserted, and the global auth
+// revision only bumped, when its code-defined Version is newer than what's
+// already registered, so a repeated call with unchanged definitions does not
+// invalidate cached permission decisions.
 func InitFixedRole() {
-	once.Do(func() {
-		// Register roles
-		FixedRolesMap.Store(usersAdminEdit, usersAdminEditRole)
-		FixedRolesMap.Store(usersAdminRead, usersAdminReadRole)
-		FixedRolesMap.Store(provisioningAdmin, provisioningAdminRole)
-		FixedRolesMap.Store(usersOrgEdit, usersOrgEditRole)
-		FixedRolesMap.Store(usersOrgRead, usersOrgReadRole)
-		FixedRolesMap.Store(ldapAdminEdit, ldapAdminEditRole)
-		FixedRolesMap.Store(ldapAdminRead, ldapAdminReadRole)
-
-		// Register assignments
-		// Grafana Admin grants
-		FixedRoleGrantsMap.Store(RoleGrafanaAdmin, []string{
-			ldapAdminEdit,
-			ldapAdminRead,
-			provisioningAdmin,
-			usersAdminEdit,
-			usersAdminRead,
-			usersOrgEdit,
-			usersOrgRead,
-		})
-		// Admin grants
-		FixedRoleGrantsMap.Store(models.ROLE_ADMIN, []string{
-			usersOrgEdit,
-			usersOrgRead,
-		})
+	upsertFixedRole(usersAdminEdit, usersAdminEditRole)
+	upsertFixedRole(usersAdminRead, usersAdminReadRole)
+	upsertFixedRole(provisioningAdmin, provisioningAdminRole)
+	upsertFixedRole(usersOrgEdit, usersOrgEditRole)
+	upsertFixedRole(usersOrgRead, usersOrgReadRole)
+	upsertFixedRole(ldapAdminEdit, ldapAdminEditRole)
+	upsertFixedRole(ldapAdminRead, ldapAdminReadRole)
+
+	// Register assignments
+	// Grafana Admin grants
+	FixedRoleGrantsMap.Store(RoleGrafanaAdmin, []string{
+		ldapAdminEdit,
+		ldapAdminRead,
+		provisioningAdmin,
+		usersAdminEdit,
+		usersAdminRead,
+		usersOrgEdit,
+		usersOrgRead,
+	})
+	// Admin grants
+	FixedRoleGrantsMap.Store(models.ROLE_ADMIN, []string{
+		usersOrgEdit,
+		usersOrgRead,
 	})
 }
 
+// upsertFixedRole stores role under name if it isn't yet registered or if
+// its Version is newer than the currently registered definition, bumping
+// the auth revision so cached permission decisions for subjects holding
+// this role are recomputed.
+func upsertFixedRole(name string, role RoleDTO) {
+	existing, ok := FixedRolesMap.Load(name)
+	if ok && existing.(RoleDTO).Version >= role.Version {
+		return
+	}
+
+	FixedRolesMap.Store(name, role)
+	bumpAuthRevision()
+}
+
 func ConcatPermissions(permissions ...[]Permission) []Permission {
 	if permissions == nil {
 		return nil
@@ -0,0 +1,22 @@
+package leakcheck
+
+import "net/http"
+
+// RegisterReportEndpoint wires the partner push receiver up behind
+// POST /api/leakcheck/report. This route must NOT be mounted behind
+// Grafana's normal admin-session/API-key auth middleware: the caller is an
+// external, unauthenticated partner (GitHub, GitLab, TruffleHog, ...) whose
+// only credential is the per-partner ECDSA signature that receiver.ServeHTTP
+// itself verifies, alongside its nonce-replay and rate-limit checks. mux is
+// expected to be (or be scoped to) an unauthenticated route group, the way
+// other externally-triggered webhook receivers are mounted.
+func RegisterReportEndpoint(mux *http.ServeMux, svc *Service, receiver *PartnerReceiver) {
+	mux.HandleFunc("/api/leakcheck/report", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		receiver.ServeHTTP(w, req, svc.HandlePush)
+	})
+}
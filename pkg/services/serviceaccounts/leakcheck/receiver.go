@@ -0,0 +1,262 @@
+package leakcheck
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// partnerReceiverPayload is the body POSTed to /api/leakcheck/report.
+// Signature covers the marshalled Reports field; Nonce guards against
+// replay and must be unique per partner within nonceTTL.
+type partnerReceiverPayload struct {
+	Partner   string          `json:"partner"`
+	Nonce     string          `json:"nonce"`
+	Signature string          `json:"signature"` // base64, ECDSA over sha256(Reports)
+	Reports   []PartnerReport `json:"reports"`
+}
+
+const nonceTTL = 10 * time.Minute
+
+// partnerKeyset holds the public keys configured for a single partner under
+// [leakcheck.partners.<name>]. A partner may rotate keys, so more than one
+// may be valid at once; any one of them verifying the signature is enough.
+type partnerKeyset struct {
+	keys []*ecdsa.PublicKey
+}
+
+func (k partnerKeyset) verify(digest, signature []byte) bool {
+	for _, key := range k.keys {
+		if ecdsa.VerifyASN1(key, digest, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// PartnerReceiver is the inbound LeakSource: it accepts pushed reports from
+// partner secret-scanning programs over HTTP and verifies them against a
+// configured keyset, but never polls anything itself.
+type PartnerReceiver struct {
+	partners map[string]partnerKeyset
+
+	nonceMu    sync.Mutex
+	seenNonces map[string]time.Time
+
+	limiter *rate.Limiter
+
+	logger log.Logger
+}
+
+// NewPartnerReceiver builds a PartnerReceiver from [leakcheck.partners.*]
+// sections in cfg, each expected to carry a `public_keys` entry: one or more
+// PEM-encoded ECDSA public keys, semicolon-separated.
+func NewPartnerReceiver(cfg *setting.Cfg) (*PartnerReceiver, error) {
+	r := &PartnerReceiver{
+		partners:   map[string]partnerKeyset{},
+		seenNonces: map[string]time.Time{},
+		// 5 requests/sec sustained, bursts up to 20, however many reports
+		// each request carries: partner pushes arrive in small batches, not
+		// a firehose.
+		limiter: rate.NewLimiter(5, 20),
+		logger:  log.New("leakcheck.receiver"),
+	}
+
+	for _, section := range cfg.Raw.Sections() {
+		const prefix = "leakcheck.partners."
+		if len(section.Name()) <= len(prefix) || section.Name()[:len(prefix)] != prefix {
+			continue
+		}
+		name := section.Name()[len(prefix):]
+
+		keyset, err := parsePartnerKeyset(section.Key("public_keys").String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid public_keys for partner %q: %w", name, err)
+		}
+		r.partners[name] = keyset
+	}
+
+	return r, nil
+}
+
+func parsePartnerKeyset(raw string) (partnerKeyset, error) {
+	var keyset partnerKeyset
+
+	for _, part := range strings.Split(raw, ";") {
+		rest := []byte(strings.TrimSpace(part))
+		for len(rest) > 0 {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return keyset, err
+			}
+			ecKey, ok := pub.(*ecdsa.PublicKey)
+			if !ok {
+				return keyset, fmt.Errorf("key is not ECDSA")
+			}
+			keyset.keys = append(keyset.keys, ecKey)
+		}
+	}
+
+	if len(keyset.keys) == 0 {
+		return keyset, fmt.Errorf("no PEM-encoded ECDSA public keys found")
+	}
+	return keyset, nil
+}
+
+// Poll implements LeakSource; the receiver is push-only.
+func (r *PartnerReceiver) Poll(ctx context.Context, hashes []string) ([]Token, error) {
+	return nil, ErrNotSupported
+}
+
+// glsaTokenPrefix marks a Grafana-issued service account token; reports
+// carrying that prefix are matched as-is, everything else is assumed to be
+// a raw secret with no stable prefix and is normalized the same way
+// TokenSource implementations like sources.DatasourceSecretSource do.
+const glsaTokenPrefix = "glsa_"
+
+// Push implements LeakSource by translating already-verified PartnerReports
+// into Tokens keyed by the same hash format CheckTokens matches against.
+// Verification happens in ServeHTTP before Push is ever called.
+func (r *PartnerReceiver) Push(ctx context.Context, reports []PartnerReport) ([]Token, error) {
+	tokens := make([]Token, 0, len(reports))
+	for _, report := range reports {
+		hash := report.Token
+		if len(hash) < len(glsaTokenPrefix) || hash[:len(glsaTokenPrefix)] != glsaTokenPrefix {
+			hash = Fingerprint(hash)
+		}
+
+		tokens = append(tokens, Token{
+			Hash:       hash,
+			URL:        report.URL,
+			ReportedAt: time.Now().Unix(),
+		})
+	}
+	return tokens, nil
+}
+
+// ServeHTTP implements POST /api/leakcheck/report. It verifies the
+// partner signature, rejects replayed nonces, and hands the payload to
+// HandlePush via the provided onReport callback.
+func (r *PartnerReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request, onReport func(ctx context.Context, reports []PartnerReport) error) {
+	if !r.limiter.Allow() {
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+
+	var payload partnerReceiverPayload
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	keyset, ok := r.partners[payload.Partner]
+	if !ok {
+		r.logger.Warn("leak report from unknown partner", "partner", payload.Partner)
+		http.Error(w, "unknown partner", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.checkNonce(payload.Partner, payload.Nonce); err != nil {
+		r.logger.Warn("leak report replay rejected", "partner", payload.Partner, "error", err)
+		http.Error(w, "replayed request", http.StatusConflict)
+		return
+	}
+
+	digest, err := canonicalDigest(payload.Reports)
+	if err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(payload.Signature)
+	if err != nil || !keyset.verify(digest, signature) {
+		r.logger.Warn("leak report signature verification failed", "partner", payload.Partner)
+		http.Error(w, "signature verification failed", http.StatusUnauthorized)
+		return
+	}
+
+	r.logger.Info("accepted leak report",
+		"partner", payload.Partner, "count", len(payload.Reports), "remote_addr", req.RemoteAddr)
+
+	if err := onReport(req.Context(), payload.Reports); err != nil {
+		r.logger.Error("failed to process leak report", "partner", payload.Partner, "error", err)
+		http.Error(w, "failed to process report", http.StatusInternalServerError)
+		return
+	}
+
+	// Only committed once the report has been fully processed: a partner's
+	// signed retry of the same nonce, triggered by a transient onReport
+	// failure above (or just a flaky first attempt), must still succeed
+	// rather than being rejected as a replay of a request we never actually
+	// finished handling.
+	r.commitNonce(payload.Partner, payload.Nonce)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// checkNonce rejects a nonce already committed by a prior, fully-processed
+// request. It does not itself mark nonce as seen; see commitNonce.
+func (r *PartnerReceiver) checkNonce(partner, nonce string) error {
+	if nonce == "" {
+		return errors.New("missing nonce")
+	}
+
+	key := partner + ":" + nonce
+
+	r.nonceMu.Lock()
+	defer r.nonceMu.Unlock()
+
+	r.evictExpiredNoncesLocked()
+
+	if _, seen := r.seenNonces[key]; seen {
+		return errors.New("nonce already used")
+	}
+	return nil
+}
+
+// commitNonce marks partner's nonce as seen, rejecting any further request
+// that reuses it within nonceTTL.
+func (r *PartnerReceiver) commitNonce(partner, nonce string) {
+	r.nonceMu.Lock()
+	defer r.nonceMu.Unlock()
+	r.seenNonces[partner+":"+nonce] = time.Now()
+}
+
+func (r *PartnerReceiver) evictExpiredNoncesLocked() {
+	cutoff := time.Now().Add(-nonceTTL)
+	for k, t := range r.seenNonces {
+		if t.Before(cutoff) {
+			delete(r.seenNonces, k)
+		}
+	}
+}
+
+func canonicalDigest(reports []PartnerReport) ([]byte, error) {
+	raw, err := json.Marshal(reports)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return sum[:], nil
+}
@@ -0,0 +1,190 @@
+package leakcheck
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func newTestReceiver(t *testing.T, partner string, pub *ecdsa.PublicKey) *PartnerReceiver {
+	t.Helper()
+	return &PartnerReceiver{
+		partners:   map[string]partnerKeyset{partner: {keys: []*ecdsa.PublicKey{pub}}},
+		seenNonces: map[string]time.Time{},
+		limiter:    rate.NewLimiter(5, 20),
+		logger:     log.New("leakcheck.receiver.test"),
+	}
+}
+
+func signedRequest(t *testing.T, priv *ecdsa.PrivateKey, partner, nonce string, reports []PartnerReport) *http.Request {
+	t.Helper()
+
+	digest, err := canonicalDigest(reports)
+	require.NoError(t, err)
+
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest)
+	require.NoError(t, err)
+
+	body, err := json.Marshal(partnerReceiverPayload{
+		Partner:   partner,
+		Nonce:     nonce,
+		Signature: base64.StdEncoding.EncodeToString(sig),
+		Reports:   reports,
+	})
+	require.NoError(t, err)
+
+	return httptest.NewRequest(http.MethodPost, "/api/leakcheck/report", strings.NewReader(string(body)))
+}
+
+func TestPartnerReceiver_ServeHTTP_ValidSignatureAccepted(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	r := newTestReceiver(t, "github", &priv.PublicKey)
+
+	reports := []PartnerReport{{Token: "glsa_abc", Source: "github"}}
+	req := signedRequest(t, priv, "github", "nonce-1", reports)
+
+	var gotReports []PartnerReport
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req, func(ctx context.Context, reports []PartnerReport) error {
+		gotReports = reports
+		return nil
+	})
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, reports, gotReports)
+}
+
+func TestPartnerReceiver_ServeHTTP_RejectsBadSignature(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	r := newTestReceiver(t, "github", &priv.PublicKey)
+
+	// Signed with a key the receiver doesn't have configured for this partner.
+	req := signedRequest(t, other, "github", "nonce-1", []PartnerReport{{Token: "glsa_abc"}})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req, func(ctx context.Context, reports []PartnerReport) error {
+		t.Fatal("onReport must not be called for a bad signature")
+		return nil
+	})
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPartnerReceiver_ServeHTTP_RejectsUnknownPartner(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	r := newTestReceiver(t, "github", &priv.PublicKey)
+
+	req := signedRequest(t, priv, "gitlab", "nonce-1", []PartnerReport{{Token: "glsa_abc"}})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req, func(ctx context.Context, reports []PartnerReport) error { return nil })
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPartnerReceiver_ServeHTTP_RejectsReplayedNonce(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	r := newTestReceiver(t, "github", &priv.PublicKey)
+
+	reports := []PartnerReport{{Token: "glsa_abc"}}
+
+	first := signedRequest(t, priv, "github", "replay-me", reports)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, first, func(ctx context.Context, reports []PartnerReport) error { return nil })
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	second := signedRequest(t, priv, "github", "replay-me", reports)
+	w2 := httptest.NewRecorder()
+	r.ServeHTTP(w2, second, func(ctx context.Context, reports []PartnerReport) error {
+		t.Fatal("onReport must not be called for a replayed nonce")
+		return nil
+	})
+	require.Equal(t, http.StatusConflict, w2.Code)
+}
+
+func TestPartnerReceiver_ServeHTTP_RetryAfterOnReportFailureIsNotTreatedAsReplay(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	r := newTestReceiver(t, "github", &priv.PublicKey)
+
+	reports := []PartnerReport{{Token: "glsa_abc"}}
+
+	first := signedRequest(t, priv, "github", "retry-me", reports)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, first, func(ctx context.Context, reports []PartnerReport) error {
+		return errors.New("transient failure")
+	})
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// The partner's signed retry reuses the same nonce: since the first
+	// attempt never finished processing, this must succeed rather than be
+	// rejected as a replay.
+	second := signedRequest(t, priv, "github", "retry-me", reports)
+	w2 := httptest.NewRecorder()
+	var gotReports []PartnerReport
+	r.ServeHTTP(w2, second, func(ctx context.Context, reports []PartnerReport) error {
+		gotReports = reports
+		return nil
+	})
+	require.Equal(t, http.StatusAccepted, w2.Code)
+	require.Equal(t, reports, gotReports)
+}
+
+func TestPartnerReceiver_ServeHTTP_RateLimited(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	r := newTestReceiver(t, "github", &priv.PublicKey)
+	r.limiter = rate.NewLimiter(0, 1) // exactly one request allowed, ever
+
+	req1 := signedRequest(t, priv, "github", "nonce-1", []PartnerReport{{Token: "glsa_abc"}})
+	w1 := httptest.NewRecorder()
+	r.ServeHTTP(w1, req1, func(ctx context.Context, reports []PartnerReport) error { return nil })
+	require.Equal(t, http.StatusAccepted, w1.Code)
+
+	req2 := signedRequest(t, priv, "github", "nonce-2", []PartnerReport{{Token: "glsa_abc"}})
+	w2 := httptest.NewRecorder()
+	r.ServeHTTP(w2, req2, func(ctx context.Context, reports []PartnerReport) error {
+		t.Fatal("onReport must not be called once the rate limit is exhausted")
+		return nil
+	})
+	require.Equal(t, http.StatusTooManyRequests, w2.Code)
+}
+
+func TestPartnerReceiver_Push_NormalizesNonGrafanaTokens(t *testing.T) {
+	r := &PartnerReceiver{}
+
+	tokens, err := r.Push(context.Background(), []PartnerReport{
+		{Token: "glsa_already-prefixed", URL: "https://example.com/a"},
+		{Token: "raw-secret-value", URL: "https://example.com/b"},
+	})
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	require.Equal(t, "glsa_already-prefixed", tokens[0].Hash)
+	require.Equal(t, Fingerprint("raw-secret-value"), tokens[1].Hash)
+}
+
+func TestPartnerReceiver_Poll_NotSupported(t *testing.T) {
+	r := &PartnerReceiver{}
+	_, err := r.Poll(context.Background(), []string{"a"})
+	require.ErrorIs(t, err, ErrNotSupported)
+}
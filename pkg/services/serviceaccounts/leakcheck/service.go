@@ -2,8 +2,8 @@ package leakcheck
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/apikey"
@@ -21,8 +21,12 @@ type CheckerClient interface {
 	CheckTokens(ctx context.Context, keyHashes []string) ([]Token, error)
 }
 
+// WebHookClient is notified of a leak by source kind and a redacted
+// identifier rather than a raw token name, since TokenSource now spans
+// secrets (datasource credentials, SMTP passwords, ...) whose names may
+// themselves be sensitive.
 type WebHookClient interface {
-	Notify(ctx context.Context, token *Token, tokenName string, revoked bool) error
+	Notify(ctx context.Context, token *Token, sourceKind, redactedIdentifier string, revoked bool) error
 }
 
 type SATokenRetriever interface {
@@ -32,8 +36,8 @@ type SATokenRetriever interface {
 
 // Leak Check Service is grafana's service for checking leaked keys.
 type Service struct {
-	store         SATokenRetriever
-	client        CheckerClient
+	sources       *SourceRegistry
+	leakSources   []LeakSource
 	webHookClient WebHookClient
 	logger        log.Logger
 	webHookNotify bool
@@ -46,9 +50,16 @@ func NewService(store SATokenRetriever, cfg *setting.Cfg) *Service {
 	oncallURL := cfg.SectionWithEnvOverrides("leakcheck").Key("oncall_url").MustString("")
 	revoke := cfg.SectionWithEnvOverrides("leakcheck").Key("revoke").MustBool(true)
 
+	client := newClient(leakcheckBaseURL, cfg.BuildVersion)
+
+	registry := NewSourceRegistry(
+		[]TokenSource{&saTokenSource{store: store}},
+		readSourceConfig(cfg),
+	)
+
 	return &Service{
-		store:         store,
-		client:        newClient(leakcheckBaseURL, cfg.BuildVersion),
+		sources:       registry,
+		leakSources:   []LeakSource{newPollOnlySource(client)},
 		webHookClient: newWebHookClient(oncallURL, cfg.BuildVersion),
 		logger:        log.New("leakcheck"),
 		webHookNotify: oncallURL != "",
@@ -56,95 +67,134 @@ func NewService(store SATokenRetriever, cfg *setting.Cfg) *Service {
 	}
 }
 
-func (s *Service) RetrieveActiveTokens(ctx context.Context) ([]apikey.APIKey, error) {
-	saTokens, err := s.store.ListTokens(ctx, &serviceaccounts.GetSATokensQuery{
-		OrgID:            nil,
-		ServiceAccountID: nil,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve service account tokens: %w", err)
+// readSourceConfig loads the enable/dry_run flags under
+// [leakcheck.sources.<kind>] for every registered TokenSource kind.
+func readSourceConfig(cfg *setting.Cfg) map[string]sourceConfig {
+	config := map[string]sourceConfig{}
+	for _, kind := range []string{
+		KindServiceAccountToken,
+		"api_key",
+		"user_auth_token",
+		"datasource_secret",
+		"smtp_password",
+		"contact_point_secret",
+		"oauth_client_secret",
+	} {
+		section := cfg.SectionWithEnvOverrides("leakcheck.sources." + kind)
+		config[kind] = sourceConfig{
+			Enabled: section.Key("enabled").MustBool(true),
+			DryRun:  section.Key("dry_run").MustBool(false),
+		}
 	}
-
-	return saTokens, nil
+	return config
 }
 
-// hasExpired returns true if the token has expired.
-// Duplicate to SA API. Remerge.
-func hasExpired(expiration *int64) bool {
-	if expiration == nil {
-		return false
-	}
-
-	v := time.Unix(*expiration, 0)
+// RegisterTokenSource adds an additional TokenSource (legacy API keys, user
+// auth tokens, datasource credentials, ...) that future calls to
+// CheckTokens will enumerate, honouring the enable/dry_run flags read from
+// [leakcheck.sources.<kind>] at NewService time.
+func (s *Service) RegisterTokenSource(source TokenSource) {
+	s.sources.register(source)
+}
 
-	return (v).Before(time.Now())
+// RegisterSource adds an additional LeakSource (e.g. the partner webhook
+// receiver) that future calls to CheckTokens will poll, and that can push
+// reports directly via HandlePush.
+func (s *Service) RegisterSource(source LeakSource) {
+	s.leakSources = append(s.leakSources, source)
 }
 
-// CheckTokens checks for leaked tokens.
+// CheckTokens checks for leaked secrets by polling every registered
+// LeakSource (e.g. leakcheck.grafana.com) with hashes batched across every
+// enabled TokenSource. Sources that only support Push (the partner webhook
+// receiver) are skipped here; they feed into HandlePush instead as reports
+// arrive.
 func (s *Service) CheckTokens(ctx context.Context) error {
-	// Retrieve all active tokens from the database.
-	tokens, err := s.RetrieveActiveTokens(ctx)
+	hashes, bySecretHash, err := s.sources.EnumerateAll(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve tokens for checking: %w", err)
+		return err
 	}
-
-	hashes, hashMap := s.filterCheckableTokens(tokens)
 	if len(hashes) == 0 {
-		s.logger.Debug("no active tokens to check")
-
+		s.logger.Debug("no active secrets to check")
 		return nil
 	}
 
-	// Check if any leaked tokens exist.
-	leakcheckTokens, err := s.client.CheckTokens(ctx, hashes)
-	if err != nil {
-		return fmt.Errorf("failed to check tokens: %w", err)
+	for _, leakSource := range s.leakSources {
+		leaked, err := leakSource.Poll(ctx, hashes)
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check tokens: %w", err)
+		}
+
+		s.handleLeaks(ctx, bySecretHash, leaked)
 	}
 
-	// Revoke leaked tokens.
-	// Could be done in bulk but we don't expect more than 1 or 2 tokens to be leaked per check.
-	for _, leakcheckToken := range leakcheckTokens {
-		leakcheckToken := leakcheckToken
-		leakedToken := hashMap[leakcheckToken.Hash]
+	return nil
+}
 
-		if s.revoke {
-			if err := s.store.RevokeServiceAccountToken(
-				ctx, leakedToken.OrgId, *leakedToken.ServiceAccountId, leakedToken.Id); err != nil {
-				s.logger.Error("failed to delete leaked token. Revoke manually.",
-					"error", err, "url", leakcheckToken.URL, "reported_at", leakcheckToken.ReportedAt,
-					"token_id", leakedToken.Id, "token", leakedToken.Name, "org", leakedToken.OrgId,
-					"serviceAccount", *leakedToken.ServiceAccountId)
-			}
-		}
+// HandlePush feeds externally-reported leaks (from the partner webhook
+// receiver) through the same hash-matching, revoke and webhook-notify path
+// CheckTokens uses for polled results. It fans the reports out across every
+// registered LeakSource that supports Push; sources that only support Poll
+// are skipped.
+func (s *Service) HandlePush(ctx context.Context, reports []PartnerReport) error {
+	_, bySecretHash, err := s.sources.EnumerateAll(ctx)
+	if err != nil {
+		return err
+	}
 
-		if s.webHookNotify {
-			if err := s.webHookClient.Notify(ctx, &leakcheckToken, leakedToken.Name, s.revoke); err != nil {
-				s.logger.Warn("failed to call token leak webhook", "error", err)
-			}
+	for _, leakSource := range s.leakSources {
+		leaked, err := leakSource.Push(ctx, reports)
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to process pushed reports: %w", err)
 		}
 
-		s.logger.Warn("found leaked token",
-			"url", leakcheckToken.URL, "reported_at", leakcheckToken.ReportedAt,
-			"token_id", leakedToken.Id, "token", leakedToken.Name, "org", leakedToken.OrgId,
-			"serviceAccount", *leakedToken.ServiceAccountId, "revoked", s.revoke)
+		s.handleLeaks(ctx, bySecretHash, leaked)
 	}
 
 	return nil
 }
 
-// filterCheckableTokens returns a list of tokens that can be checked and a map of tokens to their hashes.
-func (*Service) filterCheckableTokens(tokens []apikey.APIKey) ([]string, map[string]apikey.APIKey) {
-	hashes := make([]string, 0, len(tokens))
-	hashMap := make(map[string]apikey.APIKey)
-
-	for _, token := range tokens {
-		if hasExpired(token.Expires) || (token.IsRevoked != nil && *token.IsRevoked) {
+// handleLeaks revokes (if enabled, and the owning source isn't in dry_run)
+// and notifies on every leaked secret a LeakSource returned, matching it
+// back to the CheckableSecret it came from via bySecretHash. Could be done
+// in bulk but we don't expect more than 1 or 2 secrets to be leaked per
+// check.
+func (s *Service) handleLeaks(ctx context.Context, bySecretHash map[string]CheckableSecret, leaked []Token) {
+	for _, leakedToken := range leaked {
+		leakedToken := leakedToken
+		secret, ok := bySecretHash[leakedToken.Hash]
+		if !ok {
+			s.logger.Warn("reported leak does not match a known secret, ignoring", "url", leakedToken.URL)
 			continue
 		}
 
-		hashes = append(hashes, token.Key)
-		hashMap[token.Key] = token
-	}
+		revoked := false
+		if s.revoke {
+			if s.sources.DryRun(secret.ID.Kind) {
+				s.logger.Warn("dry_run: would have revoked leaked secret", "source", secret.ID.Kind, "secret", secret.Redacted())
+			} else if err := s.sources.Revoke(ctx, secret.ID); err != nil {
+				s.logger.Error("failed to revoke leaked secret, revoke manually",
+					"error", err, "url", leakedToken.URL, "reported_at", leakedToken.ReportedAt,
+					"source", secret.ID.Kind, "secret", secret.Redacted())
+			} else {
+				revoked = true
+			}
+		}
 
-	return hashes, hashMap
+		if s.webHookNotify {
+			if err := s.webHookClient.Notify(ctx, &leakedToken, secret.ID.Kind, secret.Redacted(), revoked); err != nil {
+				s.logger.Warn("failed to call token leak webhook", "error", err)
+			}
+		}
+
+		s.logger.Warn("found leaked secret",
+			"url", leakedToken.URL, "reported_at", leakedToken.ReportedAt,
+			"source", secret.ID.Kind, "secret", secret.Redacted(), "revoked", revoked)
+	}
 }
@@ -0,0 +1,108 @@
+package leakcheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+type fakeLeakSource struct {
+	pollLeaks []Token
+	pollErr   error
+	pushLeaks []Token
+	pushErr   error
+}
+
+func (f *fakeLeakSource) Poll(ctx context.Context, hashes []string) ([]Token, error) {
+	return f.pollLeaks, f.pollErr
+}
+
+func (f *fakeLeakSource) Push(ctx context.Context, reports []PartnerReport) ([]Token, error) {
+	return f.pushLeaks, f.pushErr
+}
+
+type fakeWebHookClient struct {
+	notifications []string
+}
+
+func (f *fakeWebHookClient) Notify(ctx context.Context, token *Token, sourceKind, redactedIdentifier string, revoked bool) error {
+	f.notifications = append(f.notifications, sourceKind+"/"+redactedIdentifier)
+	return nil
+}
+
+func TestService_HandlePush_RevokesAndNotifiesOnMatch(t *testing.T) {
+	source := &fakeTokenSource{kind: "api_key", secrets: []CheckableSecret{
+		{ID: CheckableSecretID{Kind: "api_key", Value: "1"}, Hash: "h1", Name: "token one"},
+	}}
+	registry := NewSourceRegistry([]TokenSource{source}, map[string]sourceConfig{"api_key": {Enabled: true}})
+	webhook := &fakeWebHookClient{}
+	leakSource := &fakeLeakSource{pushLeaks: []Token{{Hash: "h1", URL: "https://example.com/leak"}}}
+
+	svc := &Service{
+		sources:       registry,
+		leakSources:   []LeakSource{leakSource},
+		webHookClient: webhook,
+		logger:        log.New("leakcheck.test"),
+		webHookNotify: true,
+		revoke:        true,
+	}
+
+	require.NoError(t, svc.HandlePush(context.Background(), []PartnerReport{{Token: "h1"}}))
+	require.Equal(t, []CheckableSecretID{{Kind: "api_key", Value: "1"}}, source.revokeCalled)
+	require.Len(t, webhook.notifications, 1)
+}
+
+func TestService_HandlePush_DryRunSkipsRevoke(t *testing.T) {
+	source := &fakeTokenSource{kind: "smtp", secrets: []CheckableSecret{
+		{ID: CheckableSecretID{Kind: "smtp", Value: "smtp"}, Hash: "h1", Name: "smtp"},
+	}}
+	registry := NewSourceRegistry([]TokenSource{source}, map[string]sourceConfig{"smtp": {Enabled: true, DryRun: true}})
+	leakSource := &fakeLeakSource{pushLeaks: []Token{{Hash: "h1"}}}
+
+	svc := &Service{
+		sources:     registry,
+		leakSources: []LeakSource{leakSource},
+		logger:      log.New("leakcheck.test"),
+		revoke:      true,
+	}
+
+	require.NoError(t, svc.HandlePush(context.Background(), []PartnerReport{{Token: "h1"}}))
+	require.Empty(t, source.revokeCalled)
+}
+
+func TestService_HandlePush_UnmatchedLeakIsIgnored(t *testing.T) {
+	registry := NewSourceRegistry(nil, nil)
+	leakSource := &fakeLeakSource{pushLeaks: []Token{{Hash: "does-not-exist"}}}
+
+	svc := &Service{
+		sources:     registry,
+		leakSources: []LeakSource{leakSource},
+		logger:      log.New("leakcheck.test"),
+		revoke:      true,
+	}
+
+	require.NoError(t, svc.HandlePush(context.Background(), []PartnerReport{{Token: "does-not-exist"}}))
+}
+
+func TestService_CheckTokens_SkipsPushOnlySourcesWithoutError(t *testing.T) {
+	source := &fakeTokenSource{kind: "api_key", secrets: []CheckableSecret{
+		{ID: CheckableSecretID{Kind: "api_key", Value: "1"}, Hash: "h1"},
+	}}
+	registry := NewSourceRegistry([]TokenSource{source}, nil)
+	svc := &Service{
+		sources:     registry,
+		leakSources: []LeakSource{&PartnerReceiver{}, newPollOnlySource(&fakeCheckerClient{})},
+		logger:      log.New("leakcheck.test"),
+	}
+
+	require.NoError(t, svc.CheckTokens(context.Background()))
+}
+
+type fakeCheckerClient struct{}
+
+func (f *fakeCheckerClient) CheckTokens(ctx context.Context, keyHashes []string) ([]Token, error) {
+	return nil, nil
+}
@@ -0,0 +1,60 @@
+package leakcheck
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotSupported is returned by a LeakSource variant (Poll or Push) that
+// the concrete source doesn't implement, e.g. Push on the outbound
+// leakcheck.grafana.com client, or Poll on the inbound partner receiver.
+var ErrNotSupported = errors.New("leakcheck: operation not supported by this source")
+
+// PartnerReport is a single leak record pushed to us by an external
+// secret-scanning partner (GitHub's secret scanning partner program,
+// GitLab, TruffleHog CI, ...).
+type PartnerReport struct {
+	// Token is the leaked secret, or a normalized fingerprint of it if the
+	// partner can't forward the raw value.
+	Token string `json:"token"`
+	// Type is the partner's own label for the kind of secret, forwarded
+	// as-is into audit logs; it is not interpreted by Grafana.
+	Type string `json:"type"`
+	// URL points at the public location the secret was found exposed in.
+	URL string `json:"url"`
+	// Source identifies the partner that reported the leak, e.g. "github",
+	// "gitlab", "trufflehog".
+	Source string `json:"source"`
+}
+
+// LeakSource is one origin of leak reports CheckTokens folds into the
+// revoke+webhook pipeline. CheckerClient's outbound polling of
+// leakcheck.grafana.com and the inbound partner webhook receiver are both
+// LeakSources: the former only implements Poll, the latter only Push, each
+// returning ErrNotSupported for the variant it doesn't support.
+type LeakSource interface {
+	// Poll asks the source to check hashes against whatever leak data it
+	// has access to.
+	Poll(ctx context.Context, hashes []string) ([]Token, error)
+	// Push hands the source externally-supplied reports to translate into
+	// Tokens, using the same hash values CheckTokens already knows about.
+	Push(ctx context.Context, reports []PartnerReport) ([]Token, error)
+}
+
+// pollOnlySource adapts a CheckerClient (the only LeakSource variant that
+// existed before partner push support was added) into a LeakSource.
+type pollOnlySource struct {
+	client CheckerClient
+}
+
+func newPollOnlySource(client CheckerClient) LeakSource {
+	return &pollOnlySource{client: client}
+}
+
+func (s *pollOnlySource) Poll(ctx context.Context, hashes []string) ([]Token, error) {
+	return s.client.CheckTokens(ctx, hashes)
+}
+
+func (s *pollOnlySource) Push(ctx context.Context, reports []PartnerReport) ([]Token, error) {
+	return nil, ErrNotSupported
+}
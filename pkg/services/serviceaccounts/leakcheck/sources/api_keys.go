@@ -0,0 +1,55 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/leakcheck"
+)
+
+const KindAPIKey = "api_key"
+
+// APIKeySource enumerates legacy (non-service-account) API keys, the
+// org-scoped tokens predating service accounts that are still supported for
+// backwards compatibility.
+type APIKeySource struct {
+	store apikey.Service
+}
+
+// NewAPIKeySource returns the default TokenSource for legacy API keys.
+func NewAPIKeySource(store apikey.Service) *APIKeySource {
+	return &APIKeySource{store: store}
+}
+
+func (s *APIKeySource) Kind() string { return KindAPIKey }
+
+func (s *APIKeySource) Enumerate(ctx context.Context) ([]leakcheck.CheckableSecret, error) {
+	keys, err := s.store.GetAllAPIKeys(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	secrets := make([]leakcheck.CheckableSecret, 0, len(keys))
+	for _, key := range keys {
+		if key.IsRevoked != nil && *key.IsRevoked {
+			continue
+		}
+		secrets = append(secrets, leakcheck.CheckableSecret{
+			ID:   leakcheck.CheckableSecretID{Kind: KindAPIKey, Value: strconv.FormatInt(key.Id, 10)},
+			Hash: key.Key,
+			Name: key.Name,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (s *APIKeySource) Revoke(ctx context.Context, id leakcheck.CheckableSecretID) error {
+	keyID, err := strconv.ParseInt(id.Value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid api key id %q: %w", id.Value, err)
+	}
+	return s.store.DeleteApiKeyCtx(ctx, keyID)
+}
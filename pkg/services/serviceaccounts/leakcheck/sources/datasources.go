@@ -0,0 +1,110 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/leakcheck"
+)
+
+const KindDatasourceSecret = "datasource_secret"
+
+// DatasourceSecretSource enumerates datasource basic-auth passwords and
+// secureJsonData fields (API keys, client secrets, ...), normalized via
+// fingerprint since they have no recognisable prefix.
+type DatasourceSecretSource struct {
+	store datasources.DataSourceService
+}
+
+// NewDatasourceSecretSource returns the default TokenSource for datasource
+// credentials.
+func NewDatasourceSecretSource(store datasources.DataSourceService) *DatasourceSecretSource {
+	return &DatasourceSecretSource{store: store}
+}
+
+func (s *DatasourceSecretSource) Kind() string { return KindDatasourceSecret }
+
+func (s *DatasourceSecretSource) Enumerate(ctx context.Context) ([]leakcheck.CheckableSecret, error) {
+	q := &datasources.GetAllDataSourcesQuery{}
+	if err := s.store.GetAllDataSources(ctx, q); err != nil {
+		return nil, fmt.Errorf("failed to list datasources: %w", err)
+	}
+
+	secrets := make([]leakcheck.CheckableSecret, 0, len(q.Result))
+	for _, ds := range q.Result {
+		if ds.BasicAuthPassword != "" {
+			secrets = append(secrets, leakcheck.CheckableSecret{
+				ID:   leakcheck.CheckableSecretID{Kind: KindDatasourceSecret, Value: fmt.Sprintf("%d/%d/basicAuthPassword", ds.OrgId, ds.Id)},
+				Hash: leakcheck.Fingerprint(ds.BasicAuthPassword),
+				Name: ds.Name,
+			})
+		}
+
+		secureJSONData, err := ds.SecureJsonData.Decrypt()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt datasource %d/%d secure json data: %w", ds.OrgId, ds.Id, err)
+		}
+		for field, value := range secureJSONData {
+			secrets = append(secrets, leakcheck.CheckableSecret{
+				ID:   leakcheck.CheckableSecretID{Kind: KindDatasourceSecret, Value: fmt.Sprintf("%d/%d/%s", ds.OrgId, ds.Id, field)},
+				Hash: leakcheck.Fingerprint(value),
+				Name: ds.Name,
+			})
+		}
+	}
+
+	return secrets, nil
+}
+
+// Revoke clears the leaked field from secureJsonData (or the basic auth
+// password), leaving every other field untouched. UpdateDataSourceCommand
+// is a full-replace command, so this fetches the current datasource first
+// (the same GetDataSourceQuery pattern resolver.dsCache.HandleEvent uses)
+// and copies its fields forward rather than letting the zero-valued rest of
+// the command wipe the datasource's name, URL, type, access and JSON data.
+func (s *DatasourceSecretSource) Revoke(ctx context.Context, id leakcheck.CheckableSecretID) error {
+	var orgID, dsID int64
+	var field string
+	if _, err := fmt.Sscanf(id.Value, "%d/%d/%s", &orgID, &dsID, &field); err != nil {
+		return fmt.Errorf("invalid datasource secret id %q: %w", id.Value, err)
+	}
+
+	getQ := &datasources.GetDataSourceQuery{OrgId: orgID, Id: dsID}
+	if err := s.store.GetDataSource(ctx, getQ); err != nil {
+		return fmt.Errorf("failed to fetch datasource %d/%d: %w", orgID, dsID, err)
+	}
+	ds := getQ.Result
+
+	secureJSONData, err := ds.SecureJsonData.Decrypt()
+	if err != nil {
+		return fmt.Errorf("failed to decrypt datasource %d/%d secure json data: %w", orgID, dsID, err)
+	}
+
+	cmd := &datasources.UpdateDataSourceCommand{
+		ID:                dsID,
+		OrgID:             orgID,
+		Name:              ds.Name,
+		Type:              ds.Type,
+		Access:            ds.Access,
+		Url:               ds.Url,
+		Database:          ds.Database,
+		User:              ds.User,
+		BasicAuth:         ds.BasicAuth,
+		BasicAuthUser:     ds.BasicAuthUser,
+		BasicAuthPassword: ds.BasicAuthPassword,
+		WithCredentials:   ds.WithCredentials,
+		IsDefault:         ds.IsDefault,
+		JsonData:          ds.JsonData,
+		SecureJsonData:    secureJSONData,
+		ReadOnly:          ds.ReadOnly,
+	}
+
+	if field == "basicAuthPassword" {
+		cmd.BasicAuthPassword = ""
+	} else {
+		cmd.SecureJsonData[field] = ""
+	}
+
+	return s.store.UpdateDataSource(ctx, cmd)
+}
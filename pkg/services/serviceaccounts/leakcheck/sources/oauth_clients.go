@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/oauthserver"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/leakcheck"
+)
+
+const KindOAuthClientSecret = "oauth_client_secret"
+
+// OAuthClientSecretSource enumerates client secrets of OAuth2 clients
+// registered for Grafana's own OAuth server (used by external apps and
+// plugins authenticating as Grafana), normalized via fingerprint.
+type OAuthClientSecretSource struct {
+	store oauthserver.Store
+}
+
+// NewOAuthClientSecretSource returns the default TokenSource for OAuth
+// client secrets.
+func NewOAuthClientSecretSource(store oauthserver.Store) *OAuthClientSecretSource {
+	return &OAuthClientSecretSource{store: store}
+}
+
+func (s *OAuthClientSecretSource) Kind() string { return KindOAuthClientSecret }
+
+func (s *OAuthClientSecretSource) Enumerate(ctx context.Context) ([]leakcheck.CheckableSecret, error) {
+	clients, err := s.store.ListClients(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oauth clients: %w", err)
+	}
+
+	secrets := make([]leakcheck.CheckableSecret, 0, len(clients))
+	for _, client := range clients {
+		if client.Secret == "" {
+			continue
+		}
+		secrets = append(secrets, leakcheck.CheckableSecret{
+			ID:   leakcheck.CheckableSecretID{Kind: KindOAuthClientSecret, Value: client.ClientID},
+			Hash: leakcheck.Fingerprint(client.Secret),
+			Name: client.Name,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (s *OAuthClientSecretSource) Revoke(ctx context.Context, id leakcheck.CheckableSecretID) error {
+	return s.store.RotateClientSecret(ctx, id.Value)
+}
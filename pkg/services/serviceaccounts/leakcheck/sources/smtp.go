@@ -0,0 +1,46 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/leakcheck"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const KindSMTPPassword = "smtp_password"
+
+// SMTPPasswordSource enumerates the single SMTP password configured under
+// [smtp], if any. Unlike a database-backed source there is only ever one
+// secret, and no automated way to rotate it, so Revoke only returns an error
+// asking for manual rotation.
+type SMTPPasswordSource struct {
+	cfg *setting.Cfg
+}
+
+// NewSMTPPasswordSource returns the default TokenSource for the configured
+// SMTP password.
+func NewSMTPPasswordSource(cfg *setting.Cfg) *SMTPPasswordSource {
+	return &SMTPPasswordSource{cfg: cfg}
+}
+
+func (s *SMTPPasswordSource) Kind() string { return KindSMTPPassword }
+
+func (s *SMTPPasswordSource) Enumerate(ctx context.Context) ([]leakcheck.CheckableSecret, error) {
+	password := s.cfg.Smtp.Password
+	if password == "" {
+		return nil, nil
+	}
+
+	return []leakcheck.CheckableSecret{{
+		ID:   leakcheck.CheckableSecretID{Kind: KindSMTPPassword, Value: "smtp"},
+		Hash: leakcheck.Fingerprint(password),
+		Name: s.cfg.Smtp.Host,
+	}}, nil
+}
+
+// Revoke cannot rotate a config-file secret; it only flags the leak for an
+// administrator to act on manually.
+func (s *SMTPPasswordSource) Revoke(ctx context.Context, id leakcheck.CheckableSecretID) error {
+	return fmt.Errorf("smtp password must be rotated manually in grafana.ini")
+}
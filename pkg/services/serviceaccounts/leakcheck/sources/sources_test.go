@@ -0,0 +1,28 @@
+package sources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/leakcheck"
+)
+
+func TestSMTPPasswordSource_Revoke_AlwaysManual(t *testing.T) {
+	s := &SMTPPasswordSource{}
+	err := s.Revoke(context.Background(), leakcheck.CheckableSecretID{Kind: KindSMTPPassword, Value: "smtp"})
+	require.Error(t, err)
+}
+
+func TestContactPointSecretSource_Revoke_AlwaysManual(t *testing.T) {
+	s := &ContactPointSecretSource{}
+	err := s.Revoke(context.Background(), leakcheck.CheckableSecretID{Kind: KindContactPointSecret, Value: "1/uid/url"})
+	require.Error(t, err)
+}
+
+func TestDatasourceSecretSource_Revoke_RejectsMalformedID(t *testing.T) {
+	s := &DatasourceSecretSource{}
+	err := s.Revoke(context.Background(), leakcheck.CheckableSecretID{Kind: KindDatasourceSecret, Value: "not-a-valid-id"})
+	require.Error(t, err)
+}
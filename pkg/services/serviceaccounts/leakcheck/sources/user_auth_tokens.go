@@ -0,0 +1,53 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/leakcheck"
+)
+
+const KindUserAuthToken = "user_auth_token"
+
+// UserAuthTokenSource enumerates active long-lived user session tokens
+// (the "remember me" cookie values), hashed before checking since they
+// carry no recognisable prefix.
+type UserAuthTokenSource struct {
+	store auth.UserTokenService
+}
+
+// NewUserAuthTokenSource returns the default TokenSource for user session
+// tokens.
+func NewUserAuthTokenSource(store auth.UserTokenService) *UserAuthTokenSource {
+	return &UserAuthTokenSource{store: store}
+}
+
+func (s *UserAuthTokenSource) Kind() string { return KindUserAuthToken }
+
+func (s *UserAuthTokenSource) Enumerate(ctx context.Context) ([]leakcheck.CheckableSecret, error) {
+	active, err := s.store.GetUserTokens(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user auth tokens: %w", err)
+	}
+
+	secrets := make([]leakcheck.CheckableSecret, 0, len(active))
+	for _, token := range active {
+		secrets = append(secrets, leakcheck.CheckableSecret{
+			ID:   leakcheck.CheckableSecretID{Kind: KindUserAuthToken, Value: strconv.FormatInt(token.Id, 10)},
+			Hash: leakcheck.Fingerprint(token.AuthToken),
+			Name: fmt.Sprintf("user:%d", token.UserId),
+		})
+	}
+
+	return secrets, nil
+}
+
+func (s *UserAuthTokenSource) Revoke(ctx context.Context, id leakcheck.CheckableSecretID) error {
+	tokenID, err := strconv.ParseInt(id.Value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid user auth token id %q: %w", id.Value, err)
+	}
+	return s.store.RevokeToken(ctx, &auth.UserToken{Id: tokenID}, false)
+}
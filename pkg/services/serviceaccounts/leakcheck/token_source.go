@@ -0,0 +1,189 @@
+package leakcheck
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Fingerprint normalizes a secret that has no recognisable prefix (unlike
+// service account tokens' `glsa_...`) into a stable value safe to send to
+// the external checker: "fp_" followed by the hex-encoded SHA-256 digest of
+// the raw secret.
+// Used by TokenSource implementations whose secrets aren't already
+// distinctively prefixed, and by the partner receiver to normalize incoming
+// reports the same way before matching them against enumerated hashes.
+func Fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return "fp_" + hex.EncodeToString(sum[:])
+}
+
+// CheckableSecretID identifies a secret handed to the leak checker, scoped
+// to the TokenSource it came from so a reported leak can be routed back to
+// the right Revoke call. The Value is source-specific (a token ID, a
+// datasource ID plus field name, ...).
+type CheckableSecretID struct {
+	Kind  string
+	Value string
+}
+
+func (id CheckableSecretID) String() string {
+	return fmt.Sprintf("%s:%s", id.Kind, id.Value)
+}
+
+// CheckableSecret is one secret a TokenSource offers up for leak checking.
+// Hash is whatever value should be sent to the external checker: for
+// service account and API key tokens that's the recognisable `glsa_...`
+// value itself, but sources whose secrets have no stable prefix (datasource
+// passwords, SMTP credentials, ...) must normalize it into a fingerprint
+// (see TokenSource doc).
+type CheckableSecret struct {
+	ID   CheckableSecretID
+	Hash string
+	// Name is a human-readable, potentially sensitive label (e.g. a
+	// datasource name). It must never be sent to the external checker or
+	// logged outside an audit trail; use Redacted for anything else.
+	Name string
+}
+
+// Redacted returns an identifier safe to log or forward to a webhook: the
+// source kind plus the first and last two bytes of Name. A Name of four
+// bytes or fewer is kept whole.
+func (s CheckableSecret) Redacted() string {
+	name := s.Name
+	if len(name) > 4 {
+		name = name[:2] + "…" + name[len(name)-2:]
+	}
+	return fmt.Sprintf("%s/%s", s.ID.Kind, name)
+}
+
+// TokenSource is one origin of secrets the leak checker should watch:
+// service account tokens, legacy API keys, user auth tokens, datasource
+// credentials, SMTP passwords, alerting contact-point secrets, OAuth client
+// secrets, and so on. CheckTokens fans out Enumerate across every
+// registered, enabled source, and routes a reported leak's Revoke back to
+// whichever source produced its CheckableSecretID.
+type TokenSource interface {
+	// Kind names the source for config (`[leakcheck.sources.<kind>]`),
+	// metrics, and CheckableSecretID.Kind. Stable across releases.
+	Kind() string
+	// Enumerate lists every currently active secret this source knows
+	// about, with Hash already normalized for sending to the checker.
+	Enumerate(ctx context.Context) ([]CheckableSecret, error)
+	// Revoke invalidates the secret identified by id, previously returned
+	// from this same source's Enumerate.
+	Revoke(ctx context.Context, id CheckableSecretID) error
+}
+
+// sourceConfig is the per-source configuration read from
+// [leakcheck.sources.<kind>].
+type sourceConfig struct {
+	Enabled bool
+	// DryRun logs would-be revocations instead of performing them, without
+	// affecting the webhook notification path.
+	DryRun bool
+}
+
+// SourceRegistry holds the enabled TokenSources and their per-source
+// config, and knows how to route a reported leak's revocation back to the
+// source that produced it.
+type SourceRegistry struct {
+	sources map[string]TokenSource
+	config  map[string]sourceConfig
+}
+
+// NewSourceRegistry builds a registry containing only the sources enabled
+// in config; disabled sources are dropped entirely so they never appear in
+// Enumerate results.
+func NewSourceRegistry(all []TokenSource, config map[string]sourceConfig) *SourceRegistry {
+	reg := &SourceRegistry{
+		sources: map[string]TokenSource{},
+		config:  map[string]sourceConfig{},
+	}
+	// Keep every kind's config, not only that of the sources passed in, so
+	// a source added later through register gets its configured flags.
+	for kind, cfg := range config {
+		reg.config[kind] = cfg
+	}
+
+	for _, source := range all {
+		kind := source.Kind()
+		cfg, ok := config[kind]
+		if !ok {
+			// Sources default to enabled so a new TokenSource doesn't
+			// silently stop being checked just because nobody added a
+			// config stanza for it yet.
+			cfg = sourceConfig{Enabled: true}
+		}
+		if !cfg.Enabled {
+			continue
+		}
+
+		reg.sources[kind] = source
+		reg.config[kind] = cfg
+	}
+
+	return reg
+}
+
+// register adds source to the registry, honouring whatever config was
+// supplied for its kind at NewSourceRegistry time (or defaulting to
+// enabled, as NewSourceRegistry does).
+func (r *SourceRegistry) register(source TokenSource) {
+	kind := source.Kind()
+	cfg, ok := r.config[kind]
+	if !ok {
+		cfg = sourceConfig{Enabled: true}
+		r.config[kind] = cfg
+	}
+	if !cfg.Enabled {
+		return
+	}
+
+	r.sources[kind] = source
+}
+
+// EnumerateAll returns the hashes of every enumerated secret across every
+// enabled source as one slice, not grouped by source kind, alongside a
+// lookup map from hash to secret for matching reported leaks back to their
+// origin.
+func (r *SourceRegistry) EnumerateAll(ctx context.Context) ([]string, map[string]CheckableSecret, error) {
+	hashes := make([]string, 0)
+	bySecretHash := make(map[string]CheckableSecret)
+
+	for kind, source := range r.sources {
+		secrets, err := source.Enumerate(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to enumerate %s secrets: %w", kind, err)
+		}
+
+		for _, secret := range secrets {
+			hashes = append(hashes, secret.Hash)
+			bySecretHash[secret.Hash] = secret
+		}
+	}
+
+	return hashes, bySecretHash, nil
+}
+
+// Revoke routes a leak back to the TokenSource named by id.Kind, honouring
+// that source's dry_run setting.
+func (r *SourceRegistry) Revoke(ctx context.Context, id CheckableSecretID) error {
+	source, ok := r.sources[id.Kind]
+	if !ok {
+		return fmt.Errorf("no registered source for kind %q", id.Kind)
+	}
+
+	if r.config[id.Kind].DryRun {
+		return nil
+	}
+
+	return source.Revoke(ctx, id)
+}
+
+// DryRun reports whether kind is configured to only log would-be
+// revocations.
+func (r *SourceRegistry) DryRun(kind string) bool {
+	return r.config[kind].DryRun
+}
@@ -0,0 +1,84 @@
+package leakcheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTokenSource struct {
+	kind         string
+	secrets      []CheckableSecret
+	revokeCalled []CheckableSecretID
+}
+
+func (f *fakeTokenSource) Kind() string { return f.kind }
+
+func (f *fakeTokenSource) Enumerate(ctx context.Context) ([]CheckableSecret, error) {
+	return f.secrets, nil
+}
+
+func (f *fakeTokenSource) Revoke(ctx context.Context, id CheckableSecretID) error {
+	f.revokeCalled = append(f.revokeCalled, id)
+	return nil
+}
+
+func TestSourceRegistry_NewSourceRegistry_DropsDisabledSources(t *testing.T) {
+	enabled := &fakeTokenSource{kind: "api_key", secrets: []CheckableSecret{{ID: CheckableSecretID{Kind: "api_key", Value: "1"}, Hash: "h1"}}}
+	disabled := &fakeTokenSource{kind: "smtp", secrets: []CheckableSecret{{ID: CheckableSecretID{Kind: "smtp", Value: "smtp"}, Hash: "h2"}}}
+
+	reg := NewSourceRegistry([]TokenSource{enabled, disabled}, map[string]sourceConfig{
+		"api_key": {Enabled: true},
+		"smtp":    {Enabled: false},
+	})
+
+	hashes, bySecretHash, err := reg.EnumerateAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"h1"}, hashes)
+	require.Contains(t, bySecretHash, "h1")
+	require.NotContains(t, bySecretHash, "h2")
+}
+
+func TestSourceRegistry_NewSourceRegistry_DefaultsToEnabled(t *testing.T) {
+	source := &fakeTokenSource{kind: "oauth_client", secrets: []CheckableSecret{{ID: CheckableSecretID{Kind: "oauth_client"}, Hash: "h1"}}}
+
+	// No config entry at all for "oauth_client": must not be silently dropped.
+	reg := NewSourceRegistry([]TokenSource{source}, map[string]sourceConfig{})
+
+	hashes, _, err := reg.EnumerateAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"h1"}, hashes)
+}
+
+func TestSourceRegistry_Revoke_RoutesToOwningSource(t *testing.T) {
+	apiKeys := &fakeTokenSource{kind: "api_key"}
+	smtp := &fakeTokenSource{kind: "smtp"}
+
+	reg := NewSourceRegistry([]TokenSource{apiKeys, smtp}, map[string]sourceConfig{
+		"api_key": {Enabled: true},
+		"smtp":    {Enabled: true},
+	})
+
+	id := CheckableSecretID{Kind: "api_key", Value: "42"}
+	require.NoError(t, reg.Revoke(context.Background(), id))
+	require.Equal(t, []CheckableSecretID{id}, apiKeys.revokeCalled)
+	require.Empty(t, smtp.revokeCalled)
+}
+
+func TestSourceRegistry_Revoke_DryRunSkipsActualRevocation(t *testing.T) {
+	source := &fakeTokenSource{kind: "smtp"}
+	reg := NewSourceRegistry([]TokenSource{source}, map[string]sourceConfig{
+		"smtp": {Enabled: true, DryRun: true},
+	})
+
+	require.NoError(t, reg.Revoke(context.Background(), CheckableSecretID{Kind: "smtp", Value: "smtp"}))
+	require.Empty(t, source.revokeCalled)
+	require.True(t, reg.DryRun("smtp"))
+}
+
+func TestSourceRegistry_Revoke_UnknownKindErrors(t *testing.T) {
+	reg := NewSourceRegistry(nil, nil)
+	err := reg.Revoke(context.Background(), CheckableSecretID{Kind: "does-not-exist"})
+	require.Error(t, err)
+}
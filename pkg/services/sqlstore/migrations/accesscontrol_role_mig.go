@@ -0,0 +1,84 @@
+package migrations
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+// addAccessControlRoleMigrations creates the tables backing persisted custom
+// roles (pkg/services/accesscontrol.RoleStore): role definitions, their
+// permissions, and the grants tying a role to a built-in role or a user.
+// Call this from the top-level AddMigrations alongside the other
+// addXxxMigrations functions.
+func addAccessControlRoleMigrations(mg *migrator.Migrator) {
+	roleV1 := migrator.Table{
+		Name: "role",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "display_name", Type: migrator.DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "description", Type: migrator.DB_Text, Nullable: true},
+			{Name: "group_name", Type: migrator.DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "hidden", Type: migrator.DB_Bool, Nullable: false, Default: "0"},
+			{Name: "revision", Type: migrator.DB_BigInt, Nullable: false, Default: "1"},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "name"}, Type: migrator.UniqueIndex},
+		},
+	}
+	mg.AddMigration("create role table", migrator.NewAddTableMigration(roleV1))
+	mg.AddMigration("add unique index role.org_id-name", migrator.NewAddIndexMigration(roleV1, roleV1.Indices[0]))
+
+	permissionV1 := migrator.Table{
+		Name: "permission",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "role_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "action", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "scope", Type: migrator.DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"role_id"}},
+		},
+	}
+	mg.AddMigration("create permission table", migrator.NewAddTableMigration(permissionV1))
+	mg.AddMigration("add index permission.role_id", migrator.NewAddIndexMigration(permissionV1, permissionV1.Indices[0]))
+	// Cascading deletes for permission/builtin_role/user_role rows when their
+	// role is removed are enforced in application code (see
+	// pkg/services/accesscontrol/database), since xorm's cross-engine
+	// migrations don't give us a portable FK-cascade primitive here.
+
+	builtinRoleV1 := migrator.Table{
+		Name: "builtin_role",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "role_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "role", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "role_id", "role"}, Type: migrator.UniqueIndex},
+		},
+	}
+	mg.AddMigration("create builtin_role table", migrator.NewAddTableMigration(builtinRoleV1))
+	mg.AddMigration("add unique index builtin_role.org_id-role_id-role", migrator.NewAddIndexMigration(builtinRoleV1, builtinRoleV1.Indices[0]))
+
+	userRoleV1 := migrator.Table{
+		Name: "user_role",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "role_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "subject", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "role_id", "subject"}, Type: migrator.UniqueIndex},
+		},
+	}
+	mg.AddMigration("create user_role table", migrator.NewAddTableMigration(userRoleV1))
+	mg.AddMigration("add unique index user_role.org_id-role_id-subject", migrator.NewAddIndexMigration(userRoleV1, userRoleV1.Indices[0]))
+}
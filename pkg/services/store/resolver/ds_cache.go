@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/grafana/pkg/plugins/manager/registry"
@@ -12,6 +13,11 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/grafanads"
 )
 
+// fullResyncInterval is the safety-net refresh: even with event-driven
+// invalidation wired up, dsCache will still fully rebuild itself on this
+// cadence to recover from a missed or dropped event.
+const fullResyncInterval = time.Minute
+
 type dsVal struct {
 	InternalID   int64
 	IsDefault    bool
@@ -21,115 +27,353 @@ type dsVal struct {
 	PluginExists bool // type exists
 }
 
+// DataSourceEventType is the kind of change a DataSourceEvent describes.
+type DataSourceEventType int
+
+const (
+	DataSourceCreated DataSourceEventType = iota
+	DataSourceUpdated
+	DataSourceDeleted
+)
+
+// DataSourceEvent is published by datasources.DataSourceService whenever a
+// datasource is created, updated or deleted, letting dsCache patch just the
+// affected org instead of rebuilding the whole cache.
+type DataSourceEvent struct {
+	Type  DataSourceEventType
+	OrgID int64
+	UID   string
+}
+
+// orgCache is one org's slice of the datasource cache. real holds the
+// actual enumerated datasources, keyed by UID; full additionally carries
+// the derived lookup keys (internal ID, name, "default", "") and the
+// built-in grafana datasource, and is what getDS reads. full is rebuilt
+// from real on every mutation rather than mutated in place, which is what
+// fixes the previous implementation's bug of inserting into the very map
+// it was iterating over.
+type orgCache struct {
+	mu   sync.RWMutex
+	real map[string]*dsVal
+	full map[string]*dsVal
+}
+
+func newOrgCache(real map[string]*dsVal) *orgCache {
+	return &orgCache{real: real, full: buildFullIndex(real)}
+}
+
+// buildFullIndex builds real's full, public-facing lookup map: the primary
+// UID entries, plus (for each entry, only if nothing already claims the
+// key) a lookup by internal ID and by name, plus the built-in grafana
+// datasource and the "" / "default" aliases for whichever datasource is
+// marked default. It always starts from a fresh map, so it's safe even
+// though it reads every entry in real while deciding aux keys for every
+// other entry.
+func buildFullIndex(real map[string]*dsVal) map[string]*dsVal {
+	full := make(map[string]*dsVal, len(real)+4)
+	for uid, ds := range real {
+		full[uid] = ds
+	}
+
+	var defaultDS *dsVal
+	for _, ds := range real {
+		if ds.IsDefault {
+			defaultDS = ds
+		}
+
+		id := fmt.Sprintf("%d", ds.InternalID)
+		if _, ok := full[id]; !ok {
+			full[id] = ds
+		}
+		if _, ok := full[ds.Name]; !ok {
+			full[ds.Name] = ds
+		}
+	}
+
+	gds := &dsVal{
+		Name:         grafanads.DatasourceUID,
+		UID:          grafanads.DatasourceUID,
+		Type:         grafanads.DatasourceUID,
+		PluginExists: true,
+	}
+	full[gds.UID] = gds
+
+	if defaultDS == nil {
+		defaultDS = gds // use the internal grafana datasource
+	}
+	full[""] = defaultDS
+	if _, ok := full["default"]; !ok {
+		full["default"] = defaultDS
+	}
+
+	return full
+}
+
+func (o *orgCache) get(key string) (*dsVal, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	v, ok := o.full[key]
+	return v, ok
+}
+
+// upsert adds or replaces val by UID and rebuilds the derived index. Held
+// under this org's own lock, so lookups against every other org proceed
+// uncontended.
+func (o *orgCache) upsert(val *dsVal) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.real[val.UID] = val
+	o.full = buildFullIndex(o.real)
+}
+
+func (o *orgCache) delete(uid string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.real, uid)
+	o.full = buildFullIndex(o.real)
+}
+
+// snapshot returns every enumerated (non-aux) datasource for the org.
+func (o *orgCache) snapshot() []*dsVal {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	out := make([]*dsVal, 0, len(o.real))
+	for _, v := range o.real {
+		out = append(out, v)
+	}
+	return out
+}
+
+// dsCache resolves datasource UIDs (and the handful of legacy aliases: name,
+// internal ID, "" and "default") to dsVal, scoped per-org. getDS loads the
+// top-level org map through an atomic.Pointer without locking, and each
+// org's own data is guarded by its own RWMutex, so a write to one org's
+// datasources never blocks a read against another org's. A read does wait
+// for a write in progress on the same org, and a read that finds the cache
+// stale runs (or waits for) a full resync.
+//
+// The map is kept up to date primarily by subscribing to Created/Updated/
+// Deleted events from datasources.DataSourceService (see HandleEvent),
+// patching only the affected org. A full resync every fullResyncInterval
+// remains as a safety net in case an event is missed.
 type dsCache struct {
 	ds             datasources.DataSourceService
 	pluginRegistry registry.Service
-	cache          map[int64]map[string]*dsVal
-	timestamp      time.Time // across all orgIDs
-	mu             sync.Mutex
-}
 
-func (c *dsCache) check(ctx context.Context) error {
-	old := c.timestamp
+	cache atomic.Pointer[map[int64]*orgCache]
+
+	resyncMu       sync.Mutex // serializes full resyncs
+	lastResyncUnix atomic.Int64
+}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// NewDSCache wires up a dsCache against ds and pluginRegistry. If events is
+// non-nil, it's consumed on a background goroutine for incremental,
+// per-org invalidation (see HandleEvent); the fullResyncInterval safety net
+// runs regardless, so a nil or closed events channel just means the cache
+// falls back to polling as before.
+func NewDSCache(ctx context.Context, ds datasources.DataSourceService, pluginRegistry registry.Service, events <-chan DataSourceEvent) *dsCache {
+	c := &dsCache{ds: ds, pluginRegistry: pluginRegistry}
 
-	if c.timestamp != old {
-		return nil // already updated while we waited!
+	if events != nil {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case ev, ok := <-events:
+					if !ok {
+						return
+					}
+					// A failed patch is recovered by the next full resync,
+					// so there's nothing more useful to do with the error
+					// than drop it here.
+					_ = c.HandleEvent(ctx, ev)
+				}
+			}
+		}()
 	}
 
-	cache := make(map[int64]map[string]*dsVal, 0)
-	defaultDS := make(map[int64]*dsVal, 0)
+	return c
+}
 
-	q := &datasources.GetAllDataSourcesQuery{}
-	err := c.ds.GetAllDataSources(ctx, q)
-	if err != nil {
-		return err
+func (c *dsCache) loadOrg(orgID int64) (*orgCache, bool) {
+	cachePtr := c.cache.Load()
+	if cachePtr == nil {
+		return nil, false
 	}
+	org, ok := (*cachePtr)[orgID]
+	return org, ok
+}
 
-	for _, ds := range q.Result {
-		val := &dsVal{
-			InternalID: ds.Id,
-			Name:       ds.Name,
-			UID:        ds.Uid,
-			Type:       ds.Type,
-			IsDefault:  ds.IsDefault,
+// storeOrg publishes org as the cache entry for orgID if one doesn't already
+// exist, and returns whichever orgCache is live for orgID afterwards: org
+// itself if it won, or the instance a concurrent caller already published if
+// it lost. Callers creating a brand-new org (see HandleEvent) must upsert
+// into the returned instance, not their own local org, or they risk
+// mutating an orphaned orgCache the shared map no longer points to.
+//
+// Guarded by resyncMu so this read-modify-write of the top-level pointer
+// can't race with check()'s full-resync swap, or with another storeOrg call
+// for a different brand-new org: without the lock, two concurrent callers
+// can both load the same old map and each publish a replacement missing the
+// other's org.
+func (c *dsCache) storeOrg(orgID int64, org *orgCache) *orgCache {
+	c.resyncMu.Lock()
+	defer c.resyncMu.Unlock()
+
+	cachePtr := c.cache.Load()
+	if cachePtr != nil {
+		if existing, ok := (*cachePtr)[orgID]; ok {
+			return existing
 		}
-		_, ok := c.pluginRegistry.Plugin(ctx, val.Type)
-		val.PluginExists = ok
+	}
 
-		orgCache, ok := cache[ds.OrgId]
-		if !ok {
-			orgCache = make(map[string]*dsVal, 0)
-			cache[ds.OrgId] = orgCache
+	next := make(map[int64]*orgCache)
+	if cachePtr != nil {
+		for id, o := range *cachePtr {
+			next[id] = o
 		}
+	}
+	next[orgID] = org
+	c.cache.Store(&next)
+	return org
+}
 
-		orgCache[val.UID] = val
+// check performs a full resync of every org's datasources. Safe to call
+// concurrently: callers that lose the race for resyncMu simply reuse
+// whatever the winner just published, provided it's recent enough.
+func (c *dsCache) check(ctx context.Context) error {
+	lastResync := time.Unix(0, c.lastResyncUnix.Load())
 
-		// Empty string or
-		if val.IsDefault {
-			defaultDS[ds.OrgId] = val
-		}
+	c.resyncMu.Lock()
+	defer c.resyncMu.Unlock()
+
+	if c.cache.Load() != nil && time.Unix(0, c.lastResyncUnix.Load()).After(lastResync) {
+		return nil // already refreshed by someone else while we waited
 	}
 
-	for orgID, orgDSCache := range cache {
-		// modifies the cache we are iterating over?
-		for _, ds := range orgDSCache {
-			// Lookup by internal ID
-			id := fmt.Sprintf("%d", ds.InternalID)
-			_, ok := orgDSCache[id]
-			if !ok {
-				orgDSCache[id] = ds
-			}
+	q := &datasources.GetAllDataSourcesQuery{}
+	if err := c.ds.GetAllDataSources(ctx, q); err != nil {
+		return err
+	}
 
-			// Lookup by name
-			_, ok = orgDSCache[ds.Name]
-			if !ok {
-				orgDSCache[ds.Name] = ds
-			}
-		}
+	byOrg := make(map[int64]map[string]*dsVal)
+	for _, ds := range q.Result {
+		val := c.toVal(ctx, ds)
 
-		// Register the internal builtin grafana datasource
-		gds := &dsVal{
-			Name:         grafanads.DatasourceUID,
-			UID:          grafanads.DatasourceUID,
-			Type:         grafanads.DatasourceUID,
-			PluginExists: true,
-		}
-		orgDSCache[gds.UID] = gds
-		ds, ok := defaultDS[orgID]
+		real, ok := byOrg[ds.OrgId]
 		if !ok {
-			ds = gds // use the internal grafana datasource
-		}
-		orgDSCache[""] = ds
-		if orgDSCache["default"] == nil {
-			orgDSCache["default"] = ds
+			real = make(map[string]*dsVal)
+			byOrg[ds.OrgId] = real
 		}
+		real[val.UID] = val
 	}
 
-	c.cache = cache
-	c.timestamp = getNow()
+	next := make(map[int64]*orgCache, len(byOrg))
+	for orgID, real := range byOrg {
+		next[orgID] = newOrgCache(real)
+	}
+
+	c.cache.Store(&next)
+	c.lastResyncUnix.Store(time.Now().UnixNano())
 	return nil
 }
 
-func (c *dsCache) getDS(ctx context.Context, uid string) (*dsVal, error) {
-	var err error
-
-	// refresh cache every 1 min
-	if c.cache == nil || c.timestamp.Before(getNow().Add(time.Minute*-1)) {
-		err = c.check(ctx)
+func (c *dsCache) toVal(ctx context.Context, ds *datasources.DataSource) *dsVal {
+	val := &dsVal{
+		InternalID: ds.Id,
+		Name:       ds.Name,
+		UID:        ds.Uid,
+		Type:       ds.Type,
+		IsDefault:  ds.IsDefault,
 	}
+	_, ok := c.pluginRegistry.Plugin(ctx, val.Type)
+	val.PluginExists = ok
+	return val
+}
 
+func (c *dsCache) getDS(ctx context.Context, uid string) (*dsVal, error) {
 	orgID := store.UserFromContext(ctx).OrgID
+	return c.getDSForOrg(ctx, orgID, uid)
+}
 
-	v, ok := c.cache[orgID]
+// getDSForOrg is getDS with the org already resolved, split out so tests
+// can exercise cache behaviour without needing to fabricate a
+// store.UserFromContext-compatible context.
+func (c *dsCache) getDSForOrg(ctx context.Context, orgID int64, uid string) (*dsVal, error) {
+	if c.cache.Load() == nil || time.Since(time.Unix(0, c.lastResyncUnix.Load())) > fullResyncInterval {
+		if err := c.check(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	org, ok := c.loadOrg(orgID)
 	if !ok {
-		return nil, err // org not found
+		// An org with no datasources has no cache entry, but still resolves
+		// the built-in grafana datasource and its "" / "default" aliases.
+		org = newOrgCache(make(map[string]*dsVal))
 	}
-	ds, ok := v[uid]
+	ds, ok := org.get(uid)
 	if !ok {
-		return nil, err // data source not found
+		return nil, nil // data source not found
+	}
+	return ds, nil
+}
+
+// HandleEvent patches the cache for a single Created/Updated/Deleted
+// datasource event, without touching any other org's data. If the cache
+// hasn't been populated yet, the event is dropped; the next getDS call
+// will trigger a full resync that already reflects it.
+func (c *dsCache) HandleEvent(ctx context.Context, ev DataSourceEvent) error {
+	if c.cache.Load() == nil {
+		return nil
+	}
+
+	org, ok := c.loadOrg(ev.OrgID)
+	if !ok {
+		if ev.Type == DataSourceDeleted {
+			return nil
+		}
+		// storeOrg returns whichever orgCache actually ended up live for
+		// ev.OrgID: ours, or a concurrent HandleEvent's if it won the race
+		// to create this org first. Upserting below must target that
+		// instance, not newOrgCache's local one, or a lost race here
+		// silently orphans this event's update for up to fullResyncInterval.
+		org = c.storeOrg(ev.OrgID, newOrgCache(make(map[string]*dsVal)))
+	}
+
+	if ev.Type == DataSourceDeleted {
+		org.delete(ev.UID)
+		return nil
+	}
+
+	q := &datasources.GetDataSourceQuery{OrgId: ev.OrgID, Uid: ev.UID}
+	if err := c.ds.GetDataSource(ctx, q); err != nil {
+		return fmt.Errorf("failed to refresh datasource %d/%s: %w", ev.OrgID, ev.UID, err)
+	}
+
+	org.upsert(c.toVal(ctx, q.Result))
+	return nil
+}
+
+// HandlePluginRegistered flips PluginExists for every cached datasource of
+// the given type across every org, without a full DB refetch: a plugin
+// becoming available shouldn't require re-enumerating datasources, since
+// none of their rows changed.
+func (c *dsCache) HandlePluginRegistered(pluginType string) {
+	cachePtr := c.cache.Load()
+	if cachePtr == nil {
+		return
+	}
+
+	for _, org := range *cachePtr {
+		for _, ds := range org.snapshot() {
+			if ds.Type != pluginType || ds.PluginExists {
+				continue
+			}
+			updated := *ds
+			updated.PluginExists = true
+			org.upsert(&updated)
+		}
 	}
-	return ds, err
 }
@@ -0,0 +1,233 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+)
+
+type fakeDataSourceService struct {
+	byOrg map[int64][]*datasources.DataSource
+}
+
+func (f *fakeDataSourceService) GetAllDataSources(ctx context.Context, q *datasources.GetAllDataSourcesQuery) error {
+	for _, list := range f.byOrg {
+		q.Result = append(q.Result, list...)
+	}
+	return nil
+}
+
+func (f *fakeDataSourceService) GetDataSource(ctx context.Context, q *datasources.GetDataSourceQuery) error {
+	for _, ds := range f.byOrg[q.OrgId] {
+		if ds.Uid == q.Uid {
+			q.Result = ds
+			return nil
+		}
+	}
+	return fmt.Errorf("not found")
+}
+
+type fakePluginRegistry struct {
+	mu     sync.Mutex
+	exists map[string]bool
+}
+
+func (f *fakePluginRegistry) Plugin(ctx context.Context, id string) (*plugins.Plugin, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return nil, f.exists[id]
+}
+
+func newTestCache(svc *fakeDataSourceService, reg *fakePluginRegistry) *dsCache {
+	return &dsCache{ds: svc, pluginRegistry: reg}
+}
+
+func TestDsCache_ConcurrentReadWrite(t *testing.T) {
+	svc := &fakeDataSourceService{byOrg: map[int64][]*datasources.DataSource{
+		1: {{Id: 1, OrgId: 1, Uid: "a", Name: "A", Type: "prometheus"}},
+		2: {{Id: 2, OrgId: 2, Uid: "b", Name: "B", Type: "loki"}},
+	}}
+	reg := &fakePluginRegistry{exists: map[string]bool{"prometheus": true, "loki": true}}
+	c := newTestCache(svc, reg)
+
+	require.NoError(t, c.check(context.Background()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = c.getDSForOrg(context.Background(), 1, "a")
+		}()
+		go func() {
+			defer wg.Done()
+			require.NoError(t, c.HandleEvent(context.Background(), DataSourceEvent{
+				Type: DataSourceUpdated, OrgID: 2, UID: "b",
+			}))
+		}()
+	}
+	wg.Wait()
+
+	ds, err := c.getDSForOrg(context.Background(), 1, "a")
+	require.NoError(t, err)
+	require.NotNil(t, ds)
+	require.Equal(t, "a", ds.UID)
+}
+
+func TestDsCache_HandleEvent_PatchesCreatedAndDeleted(t *testing.T) {
+	svc := &fakeDataSourceService{byOrg: map[int64][]*datasources.DataSource{
+		1: {{Id: 1, OrgId: 1, Uid: "a", Name: "A", Type: "prometheus"}},
+	}}
+	reg := &fakePluginRegistry{exists: map[string]bool{"prometheus": true}}
+	c := newTestCache(svc, reg)
+	require.NoError(t, c.check(context.Background()))
+
+	svc.byOrg[1] = append(svc.byOrg[1], &datasources.DataSource{Id: 2, OrgId: 1, Uid: "c", Name: "C", Type: "prometheus"})
+	require.NoError(t, c.HandleEvent(context.Background(), DataSourceEvent{Type: DataSourceCreated, OrgID: 1, UID: "c"}))
+
+	ds, err := c.getDSForOrg(context.Background(), 1, "c")
+	require.NoError(t, err)
+	require.NotNil(t, ds)
+
+	require.NoError(t, c.HandleEvent(context.Background(), DataSourceEvent{Type: DataSourceDeleted, OrgID: 1, UID: "a"}))
+	ds, err = c.getDSForOrg(context.Background(), 1, "a")
+	require.NoError(t, err)
+	require.Nil(t, ds)
+}
+
+func TestDsCache_PluginRegistered_FlipsExistsWithoutRefetch(t *testing.T) {
+	svc := &fakeDataSourceService{byOrg: map[int64][]*datasources.DataSource{
+		1: {{Id: 1, OrgId: 1, Uid: "a", Name: "A", Type: "newplugin"}},
+	}}
+	reg := &fakePluginRegistry{exists: map[string]bool{}}
+	c := newTestCache(svc, reg)
+	require.NoError(t, c.check(context.Background()))
+
+	ds, err := c.getDSForOrg(context.Background(), 1, "a")
+	require.NoError(t, err)
+	require.False(t, ds.PluginExists)
+
+	reg.mu.Lock()
+	reg.exists["newplugin"] = true
+	reg.mu.Unlock()
+	c.HandlePluginRegistered("newplugin")
+
+	ds, err = c.getDSForOrg(context.Background(), 1, "a")
+	require.NoError(t, err)
+	require.True(t, ds.PluginExists)
+}
+
+func TestDsCache_DefaultDatasourceChurn(t *testing.T) {
+	svc := &fakeDataSourceService{byOrg: map[int64][]*datasources.DataSource{
+		1: {
+			{Id: 1, OrgId: 1, Uid: "a", Name: "A", Type: "prometheus", IsDefault: true},
+			{Id: 2, OrgId: 1, Uid: "b", Name: "B", Type: "loki"},
+		},
+	}}
+	reg := &fakePluginRegistry{exists: map[string]bool{"prometheus": true, "loki": true}}
+	c := newTestCache(svc, reg)
+	require.NoError(t, c.check(context.Background()))
+
+	def, err := c.getDSForOrg(context.Background(), 1, "")
+	require.NoError(t, err)
+	require.Equal(t, "a", def.UID)
+
+	svc.byOrg[1][0].IsDefault = false
+	svc.byOrg[1][1].IsDefault = true
+	require.NoError(t, c.HandleEvent(context.Background(), DataSourceEvent{Type: DataSourceUpdated, OrgID: 1, UID: "a"}))
+	require.NoError(t, c.HandleEvent(context.Background(), DataSourceEvent{Type: DataSourceUpdated, OrgID: 1, UID: "b"}))
+
+	def, err = c.getDSForOrg(context.Background(), 1, "")
+	require.NoError(t, err)
+	require.Equal(t, "b", def.UID)
+}
+
+func TestDsCache_ConcurrentNewOrgsDontLoseEachOther(t *testing.T) {
+	svc := &fakeDataSourceService{byOrg: map[int64][]*datasources.DataSource{
+		1: {{Id: 1, OrgId: 1, Uid: "a", Name: "A", Type: "prometheus"}},
+	}}
+	reg := &fakePluginRegistry{exists: map[string]bool{"prometheus": true}}
+	c := newTestCache(svc, reg)
+	require.NoError(t, c.check(context.Background()))
+
+	// Two brand-new orgs appearing back-to-back both call storeOrg, which
+	// publishes a whole new top-level map; neither call's org must be lost.
+	svc.byOrg[2] = []*datasources.DataSource{{Id: 2, OrgId: 2, Uid: "b", Name: "B", Type: "prometheus"}}
+	svc.byOrg[3] = []*datasources.DataSource{{Id: 3, OrgId: 3, Uid: "c", Name: "C", Type: "prometheus"}}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, c.HandleEvent(context.Background(), DataSourceEvent{Type: DataSourceCreated, OrgID: 2, UID: "b"}))
+	}()
+	go func() {
+		defer wg.Done()
+		require.NoError(t, c.HandleEvent(context.Background(), DataSourceEvent{Type: DataSourceCreated, OrgID: 3, UID: "c"}))
+	}()
+	wg.Wait()
+
+	ds2, err := c.getDSForOrg(context.Background(), 2, "b")
+	require.NoError(t, err)
+	require.NotNil(t, ds2)
+
+	ds3, err := c.getDSForOrg(context.Background(), 3, "c")
+	require.NoError(t, err)
+	require.NotNil(t, ds3)
+}
+
+func TestDsCache_ConcurrentHandleEvent_SameNewOrgDoesNotOrphanUpdate(t *testing.T) {
+	svc := &fakeDataSourceService{byOrg: map[int64][]*datasources.DataSource{
+		1: {{Id: 1, OrgId: 1, Uid: "a", Name: "A", Type: "prometheus"}},
+	}}
+	reg := &fakePluginRegistry{exists: map[string]bool{"prometheus": true}}
+	c := newTestCache(svc, reg)
+	require.NoError(t, c.check(context.Background()))
+
+	// Org 2 doesn't exist in the cache yet; two events for it race to create
+	// the orgCache. Whichever HandleEvent call loses the storeOrg race must
+	// still upsert into the winning instance, not its own orphaned one.
+	svc.byOrg[2] = []*datasources.DataSource{
+		{Id: 2, OrgId: 2, Uid: "b", Name: "B", Type: "prometheus"},
+		{Id: 3, OrgId: 2, Uid: "c", Name: "C", Type: "prometheus"},
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, c.HandleEvent(context.Background(), DataSourceEvent{Type: DataSourceCreated, OrgID: 2, UID: "b"}))
+	}()
+	go func() {
+		defer wg.Done()
+		require.NoError(t, c.HandleEvent(context.Background(), DataSourceEvent{Type: DataSourceCreated, OrgID: 2, UID: "c"}))
+	}()
+	wg.Wait()
+
+	dsB, err := c.getDSForOrg(context.Background(), 2, "b")
+	require.NoError(t, err)
+	require.NotNil(t, dsB)
+
+	dsC, err := c.getDSForOrg(context.Background(), 2, "c")
+	require.NoError(t, err)
+	require.NotNil(t, dsC)
+}
+
+func TestDsCache_BuiltinGrafanaDatasourceAlwaysPresent(t *testing.T) {
+	svc := &fakeDataSourceService{byOrg: map[int64][]*datasources.DataSource{1: {}}}
+	reg := &fakePluginRegistry{exists: map[string]bool{}}
+	c := newTestCache(svc, reg)
+	require.NoError(t, c.check(context.Background()))
+
+	ds, err := c.getDSForOrg(context.Background(), 1, grafanads.DatasourceUID)
+	require.NoError(t, err)
+	require.NotNil(t, ds)
+	require.True(t, ds.PluginExists)
+}